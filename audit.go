@@ -0,0 +1,79 @@
+package logx
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// auditTag is the logger Audit writes to; auditRequired makes InitLogger
+// fail when it is not configured
+var auditTag = "audit"
+var auditRequired bool
+
+// auditMissing is set, under lock, when the last InitLogger configured no
+// logger for auditTag. auditUsed is 1 once Audit was called and
+// auditWarned once that was reported for the current configuration.
+var auditMissing bool
+var auditUsed int32
+var auditWarned int32
+
+// SetAuditTag binds Audit to the logger configured under tag and makes
+// InitLogger return an error when no such logger is defined.
+func SetAuditTag(tag string) {
+	lock.Lock()
+	defer lock.Unlock()
+	auditTag = tag
+	auditRequired = true
+}
+
+// checkAudit reports a missing audit logger among those registered and
+// built: an error after SetAuditTag, otherwise a warning once Audit is
+// used. Callers hold lock.
+func checkAudit(built map[string]*logrus.Logger) error {
+	_, ok := built[auditTag]
+	if _, registered := loggers[auditTag]; !ok && !registered {
+		if auditRequired {
+			return fmt.Errorf("audit logger %q is not configured", auditTag)
+		}
+		auditMissing = true
+		atomic.StoreInt32(&auditWarned, 0)
+		if atomic.LoadInt32(&auditUsed) == 1 {
+			warnAudit(auditTag)
+		}
+		return nil
+	}
+	auditMissing = false
+	return nil
+}
+
+// warnAudit reports, once per configuration, that Audit writes to a
+// logger that is not configured
+func warnAudit(tag string) {
+	if atomic.CompareAndSwapInt32(&auditWarned, 0, 1) {
+		logrus.Warnf("logx: audit logger %q is not configured, audit entries go to the fallback of GetLogger", tag)
+	}
+}
+
+// Audit returns an entry on the audit logger carrying the required actor,
+// action and resource fields. Add more fields and finish it with Info, e.g.
+//
+//	logx.Audit("alice", "delete", "bucket/logs").WithField("ip", ip).Info("object deleted")
+//
+// When InitLogger configured no audit logger, a warning goes to the
+// standard logger the first time Audit is used.
+func Audit(actor, action, resource string) *logrus.Entry {
+	lock.RLock()
+	tag, missing := auditTag, auditMissing
+	lock.RUnlock()
+	atomic.StoreInt32(&auditUsed, 1)
+	if missing {
+		warnAudit(tag)
+	}
+	return GetLogger(tag).WithFields(logrus.Fields{
+		"actor":    actor,
+		"action":   action,
+		"resource": resource,
+	})
+}
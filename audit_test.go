@@ -0,0 +1,90 @@
+package logx
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestAudit(t *testing.T) {
+	defer Snapshot()()
+	out := &syncBuffer{}
+	lock.Lock()
+	loggers["audit"] = newTestLogger("audit", out)
+	lock.Unlock()
+
+	Audit("alice", "delete", "bucket/logs").WithField("ip", "10.0.0.1").Info("object deleted")
+	line := out.Lines()[0]
+	for _, want := range []string{"actor=alice", "action=delete", "resource=bucket/logs", "ip=10.0.0.1"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("%q is missing %s", line, want)
+		}
+	}
+}
+
+func TestAuditRequired(t *testing.T) {
+	defer Snapshot()()
+	SetAuditTag("compliance")
+	defer func() { auditTag, auditRequired = "audit", false }()
+
+	dir := t.TempDir()
+	if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "app", Type: "console", Level: "info"}}); nil == err {
+		t.Error("missing audit logger not reported")
+	}
+	if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "compliance", Type: "console", Level: "info"}}); nil != err {
+		t.Error(err)
+	}
+}
+
+func TestAuditNotConfigured(t *testing.T) {
+	defer Snapshot()()
+	atomic.StoreInt32(&auditUsed, 0)
+	defer func() {
+		auditMissing = false
+		atomic.StoreInt32(&auditUsed, 0)
+		atomic.StoreInt32(&auditWarned, 0)
+	}()
+	hook := test.NewLocal(logrus.StandardLogger())
+	warnings := func() int {
+		n := 0
+		for _, e := range hook.AllEntries() {
+			if strings.Contains(e.Message, `audit logger "audit" is not configured`) {
+				n++
+			}
+		}
+		hook.Reset()
+		return n
+	}
+
+	dir := t.TempDir()
+	if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "app", Type: "file", Level: "info"}}); nil != err {
+		t.Fatal(err)
+	}
+	if n := warnings(); n != 0 {
+		t.Errorf("%d warnings before Audit was used", n)
+	}
+	Audit("alice", "delete", "bucket/logs").Info("object deleted")
+	Audit("alice", "delete", "bucket/tmp").Info("object deleted")
+	if n := warnings(); n != 1 {
+		t.Errorf("%d warnings after Audit, want 1", n)
+	}
+
+	// Audit in use, a configuration still lacking it is reported at once
+	if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "other", Type: "file", Level: "info"}}); nil != err {
+		t.Fatal(err)
+	}
+	if n := warnings(); n != 1 {
+		t.Errorf("%d warnings from InitLogger, want 1", n)
+	}
+
+	if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "audit", Type: "file", Level: "info"}}); nil != err {
+		t.Fatal(err)
+	}
+	Audit("alice", "delete", "bucket/logs").Info("object deleted")
+	if n := warnings(); n != 0 {
+		t.Errorf("%d warnings with the audit logger configured", n)
+	}
+}
@@ -0,0 +1,89 @@
+package logx
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/sirupsen/logrus"
+	prefixed "github.com/x-cray/logrus-prefixed-formatter"
+)
+
+// autoFormatter is the "auto" format: the colored human format of the
+// console when the output is a terminal, a compact single-line logfmt
+// without colors otherwise, such as under systemd, where journald adds its
+// own timestamps. It renders compact until configureAuto saw the output.
+type autoFormatter struct {
+	tty     bool
+	human   logrus.Formatter
+	compact logrus.Formatter
+}
+
+func newAutoFormatter() *autoFormatter {
+	return &autoFormatter{
+		human: &prefixed.TextFormatter{
+			FullTimestamp:   true,
+			TimestampFormat: txtFormatter.TimestampFormat,
+			ForceFormatting: true,
+			ForceColors:     true,
+		},
+		compact: &logrus.TextFormatter{
+			DisableColors:   true,
+			FullTimestamp:   true,
+			TimestampFormat: time.RFC3339,
+		},
+	}
+}
+
+func (f *autoFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	if f.tty {
+		return f.human.Format(e)
+	}
+	return f.compact.Format(e)
+}
+
+// terminalWriter is implemented by outputs that know whether they end in a
+// terminal better than their file descriptor tells
+type terminalWriter interface {
+	IsTerminal() bool
+}
+
+// isTerminal reports whether w, seen through the wrappers of console
+// outputs, is a terminal
+func isTerminal(w io.Writer) bool {
+	switch t := w.(type) {
+	case terminalWriter:
+		return t.IsTerminal()
+	case *os.File:
+		return isatty.IsTerminal(t.Fd()) || isatty.IsCygwinTerminal(t.Fd())
+	case *bufferedWriter:
+		return isTerminal(t.out)
+	case noCloseWriter:
+		return isTerminal(t.Writer)
+	}
+	return false
+}
+
+// configureAuto points the auto formats of the entryFormatter f at out, the
+// output they render for
+func configureAuto(f logrus.Formatter, out io.Writer) {
+	ef, ok := f.(*entryFormatter)
+	if !ok {
+		return
+	}
+	tty := isTerminal(out)
+	switch inner := ef.inner.(type) {
+	case *autoFormatter:
+		inner.tty = tty
+	case *levelFormatter:
+		if af, ok := inner.def.(*autoFormatter); ok {
+			af.tty = tty
+		}
+		for _, lf := range inner.byLevel {
+			if af, ok := lf.(*autoFormatter); ok {
+				af.tty = tty
+			}
+		}
+	}
+}
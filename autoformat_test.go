@@ -0,0 +1,54 @@
+package logx
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeTerminal is an output that claims to be a terminal or not
+type fakeTerminal struct {
+	syncBuffer
+	tty bool
+}
+
+func (f *fakeTerminal) IsTerminal() bool {
+	return f.tty
+}
+
+func TestAutoFormat(t *testing.T) {
+	for _, tty := range []bool{true, false} {
+		out := &fakeTerminal{tty: tty}
+		inner, err := filterFormatter(map[string]string{"format": "auto"})
+		if nil != err {
+			t.Fatal(err)
+		}
+		l := logrus.New()
+		l.SetFormatter(newEntryFormatter("auto", inner))
+		configureAuto(l.Formatter, out)
+		l.SetOutput(out)
+		l.WithTime(time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)).WithField("user", "ada").Warn("two\nlines")
+
+		lines := out.Lines()
+		got := strings.Join(lines, "\n")
+		if tty {
+			if !strings.Contains(got, "\x1b[") || !strings.Contains(got, "[2023-01-01.10:00:00]") {
+				t.Errorf("terminal output not in the colored human format: %q", got)
+			}
+			continue
+		}
+		want := `time="2023-01-01T10:00:00Z" level=warning msg="two\nlines" user=ada`
+		if len(lines) != 1 || lines[0] != want {
+			t.Errorf("non-terminal output %q, want %q", got, want)
+		}
+	}
+
+	if isTerminal(&syncBuffer{}) {
+		t.Error("a buffer is a terminal")
+	}
+	if !isTerminal(noCloseWriter{&fakeTerminal{tty: true}}) {
+		t.Error("console wrapper hides the terminal")
+	}
+}
@@ -0,0 +1,144 @@
+package logx
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultFlushInterval = time.Second
+
+func init() {
+	// Fatal exits right after writing; push buffered lines out first
+	logrus.RegisterExitHandler(func() { Flush() })
+}
+
+// bufferedWriter coalesces writes to out and flushes them every interval,
+// on Flush/Close, and right away for entries marked urgent
+type bufferedWriter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	buf    *bufio.Writer
+	urgent int32
+	stop   chan struct{}
+	once   sync.Once
+}
+
+func newBufferedWriter(out io.Writer, size int, interval time.Duration) *bufferedWriter {
+	w := &bufferedWriter{
+		out:  out,
+		buf:  bufio.NewWriterSize(out, size),
+		stop: make(chan struct{}),
+	}
+	go w.loop(interval)
+	return w
+}
+
+func (w *bufferedWriter) loop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.Flush()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.buf.Write(p)
+	if nil == err && atomic.LoadInt32(&w.urgent) > 0 {
+		atomic.AddInt32(&w.urgent, -1)
+		err = w.buf.Flush()
+	}
+	return n, err
+}
+
+func (w *bufferedWriter) unwrap() io.Writer {
+	return w.out
+}
+
+func (w *bufferedWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Flush()
+}
+
+// Close stops the background flusher, flushes and closes out
+func (w *bufferedWriter) Close() error {
+	w.once.Do(func() { close(w.stop) })
+	err := w.Flush()
+	if c, ok := w.out.(io.Closer); ok {
+		if cerr := c.Close(); nil == err {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// urgentHook makes the next write of entries at its levels bypass the
+// buffer
+type urgentHook struct {
+	w      *bufferedWriter
+	levels []logrus.Level
+}
+
+func (h urgentHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// fatalLevels are flushed right away by buffered files, errorLevels by the
+// buffered console
+var fatalLevels = []logrus.Level{logrus.PanicLevel, logrus.FatalLevel}
+var errorLevels = []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+
+func (h urgentHook) Fire(e *logrus.Entry) error {
+	atomic.AddInt32(&h.w.urgent, 1)
+	return nil
+}
+
+// bufferOutput wraps out in a buffered writer when the bufsize property is
+// set; flushinterval (default 1s) bounds how long a line may sit in the
+// buffer and entries at the urgent levels are written right away. Without
+// bufsize out is returned unchanged. A nil l is for outputs that flush
+// urgent entries themselves, as they know the level of each write.
+func bufferOutput(l *logrus.Logger, out io.Writer, props map[string]string, urgent []logrus.Level) io.Writer {
+	size := strToNumSuffix(props["bufsize"], 1024)
+	if size <= 0 {
+		return out
+	}
+	interval, err := time.ParseDuration(props["flushinterval"])
+	if nil != err || interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	w := newBufferedWriter(out, size, interval)
+	if nil != l {
+		l.AddHook(urgentHook{w: w, levels: urgent})
+	}
+	return w
+}
+
+// consoleOutput is stdout, buffered like files when bufsize is set to save
+// a syscall per line under load. Error and more severe entries still go out
+// right away, and Flush and Close push the rest; stdout itself is never
+// closed.
+func consoleOutput(l *logrus.Logger, props map[string]string) io.Writer {
+	if strToNumSuffix(props["bufsize"], 1024) <= 0 {
+		return os.Stdout
+	}
+	return bufferOutput(l, noCloseWriter{os.Stdout}, props, errorLevels)
+}
+
+// noCloseWriter hides the Close method of the writer it wraps
+type noCloseWriter struct {
+	io.Writer
+}
@@ -0,0 +1,117 @@
+package logx
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBufferedOutput(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "buffered", Type: "file", Level: "info",
+		Properties: map[string]string{"bufsize": "64K", "flushinterval": "200ms"}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	filename := path.Join(dir, "buffered.log")
+	read := func() string {
+		content, _ := ioutil.ReadFile(filename)
+		return string(content)
+	}
+
+	l := GetLogger("buffered")
+	l.Info("held back")
+	if strings.Contains(read(), "held back") {
+		t.Fatal("buffered line written immediately")
+	}
+	time.Sleep(500 * time.Millisecond)
+	if !strings.Contains(read(), "held back") {
+		t.Fatal("buffered line not flushed after the interval")
+	}
+
+	func() {
+		defer func() { recover() }()
+		l.Panic("urgent")
+	}()
+	if !strings.Contains(read(), "urgent") {
+		t.Error("panic entry was buffered")
+	}
+
+	l.Info("on close")
+	Close()
+	if !strings.Contains(read(), "on close") {
+		t.Error("Close did not flush")
+	}
+}
+
+// redirectStdout points os.Stdout at a temporary file until the returned
+// restore is called
+func redirectStdout(tb testing.TB) (*os.File, func()) {
+	f, err := os.Create(filepath.Join(tb.TempDir(), "stdout"))
+	if nil != err {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { f.Close() })
+	stdout := os.Stdout
+	os.Stdout = f
+	return f, func() { os.Stdout = stdout }
+}
+
+func TestBufferedConsole(t *testing.T) {
+	f, restore := redirectStdout(t)
+	built, writers, err := buildLoggers(t.TempDir(), []FilterConfig{{Tag: "console", Type: "console", Level: "info",
+		Properties: map[string]string{"bufsize": "64K", "flushinterval": "1h"}}})
+	restore()
+	if nil != err {
+		t.Fatal(err)
+	}
+	read := func() string {
+		contents, _ := ioutil.ReadFile(f.Name())
+		return string(contents)
+	}
+
+	l := built["console"]
+	l.Info("coalesced")
+	if got := read(); got != "" {
+		t.Errorf("info written through: %q", got)
+	}
+	l.Error("urgent")
+	if got := read(); !strings.Contains(got, "coalesced") || !strings.Contains(got, "urgent") {
+		t.Errorf("error did not flush: %q", got)
+	}
+	l.Info("at shutdown")
+	if err := closeOutput("console", writers["console"]); nil != err {
+		t.Fatal(err)
+	}
+	if got := read(); !strings.Contains(got, "at shutdown") {
+		t.Errorf("close did not flush: %q", got)
+	}
+}
+
+func benchmarkConsole(b *testing.B, props map[string]string) {
+	_, restore := redirectStdout(b)
+	defer restore()
+	built, writers, err := buildLoggers(b.TempDir(), []FilterConfig{{Tag: "console", Type: "console", Level: "info", Properties: props}})
+	if nil != err {
+		b.Fatal(err)
+	}
+	l := built["console"]
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Info("a line of moderate length for the throughput benchmark")
+	}
+	closeOutput("console", writers["console"])
+}
+
+func BenchmarkConsoleUnbuffered(b *testing.B) {
+	benchmarkConsole(b, nil)
+}
+
+func BenchmarkConsoleBuffered(b *testing.B) {
+	benchmarkConsole(b, map[string]string{"bufsize": "64K"})
+}
@@ -0,0 +1,68 @@
+package logx
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// callerSkip lists the packages whose frames are never reported as the
+// caller: logrus already skips its own, this adds the hook libraries and
+// logx itself so entries logged through the package helpers point at the
+// code that called them
+var callerSkip = []string{
+	reflect.TypeOf(entryFormatter{}).PkgPath() + ".",
+	"github.com/sirupsen/logrus.",
+	"github.com/rifflock/lfshook.",
+	"runtime.",
+}
+
+func skipFrame(f runtime.Frame) bool {
+	if strings.HasSuffix(f.File, "_test.go") {
+		return false
+	}
+	for _, prefix := range callerSkip {
+		if strings.HasPrefix(f.Function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// withCaller returns e with its caller moved past the logx frames and, for
+// formatters other than JSON (which renders func and file on its own),
+// added as a caller=file:line field
+func withCaller(e *logrus.Entry, inner logrus.Formatter) *logrus.Entry {
+	caller := *e.Caller
+	if skipFrame(caller) {
+		pcs := make([]uintptr, 32)
+		frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+		for {
+			f, more := frames.Next()
+			if !skipFrame(f) {
+				caller = f
+				break
+			}
+			if !more {
+				break
+			}
+		}
+	}
+
+	e = cloneEntry(e)
+	e.Caller = &caller
+	if lf, ok := inner.(*levelFormatter); ok {
+		inner = lf.def
+		if f, ok := lf.byLevel[e.Level]; ok {
+			inner = f
+		}
+	}
+	if _, ok := inner.(*logrus.JSONFormatter); !ok {
+		e.Data["caller"] = fmt.Sprintf("%s:%d", filepath.Base(caller.File), caller.Line)
+	}
+	return e
+}
@@ -0,0 +1,43 @@
+package logx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestReportCaller(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{
+		{Tag: "debug", Type: "console", Level: "info", Properties: map[string]string{"reportcaller": "true"}},
+		{Tag: "plain", Type: "console", Level: "info"},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	debugOut, plainOut := &syncBuffer{}, &syncBuffer{}
+	GetLogger("debug").SetOutput(debugOut)
+	GetLogger("plain").SetOutput(plainOut)
+
+	GetLogger("debug").Info("with caller")
+	GetLogger("plain").Info("without caller")
+	HTTPMiddleware("debug")(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	lines := debugOut.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("debug logged %q", lines)
+	}
+	if !regexp.MustCompile(` caller=caller_test\.go:\d+`).MatchString(lines[0]) {
+		t.Errorf("caller not reported in %q", lines[0])
+	}
+	// the middleware logs on behalf of net/http, not from logx itself
+	if !strings.Contains(lines[1], " caller=") || strings.Contains(lines[1], "caller=http.go") {
+		t.Errorf("helper frame not skipped in %q", lines[1])
+	}
+	if line := plainOut.Lines()[0]; strings.Contains(line, "caller=") {
+		t.Errorf("caller reported on plain: %q", line)
+	}
+}
@@ -0,0 +1,68 @@
+package logx
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// clfTimeFormat is the %t layout of the Apache log formats
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// clfFormatter renders entries in the Apache/Nginx combined log format
+//
+//	remote_addr - user [time] "request" status bytes "referer" "user_agent"
+//
+// from the fields of those names; missing fields print as "-". time
+// defaults to the entry time, and request to "method path" when the entry
+// has those fields, as HTTPMiddleware entries do. Other fields and the
+// message are not written.
+type clfFormatter struct{}
+
+func (clfFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	b := e.Buffer
+	if nil == b {
+		b = &bytes.Buffer{}
+	}
+	host := clfField(e.Data, "remote_addr")
+	if h, _, err := net.SplitHostPort(host); nil == err {
+		host = h
+	}
+	t := e.Time
+	if v, ok := e.Data["time"].(time.Time); ok {
+		t = v
+	}
+	request := clfField(e.Data, "request")
+	if _, ok := e.Data["request"]; !ok {
+		if method, ok := e.Data["method"]; ok {
+			request = fmt.Sprintf("%v %v", method, clfField(e.Data, "path"))
+		}
+	}
+	fmt.Fprintf(b, "%s - %s [%s] %s %s %s %s %s\n",
+		host, clfField(e.Data, "user"), t.Format(clfTimeFormat),
+		clfQuote(request), clfField(e.Data, "status"), clfField(e.Data, "bytes"),
+		clfQuote(clfField(e.Data, "referer")), clfQuote(clfField(e.Data, "user_agent")))
+	return b.Bytes(), nil
+}
+
+// clfField renders a field, or "-" when it is missing or empty
+func clfField(data logrus.Fields, key string) string {
+	v, ok := data[key]
+	if !ok {
+		return "-"
+	}
+	s := fmt.Sprint(v)
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// clfQuote quotes s the way Apache escapes request strings
+func clfQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -0,0 +1,37 @@
+package logx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCLFFormatter(t *testing.T) {
+	at := time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600))
+	e := &logrus.Entry{Time: at, Level: logrus.InfoLevel, Message: "ignored", Data: logrus.Fields{
+		"remote_addr": "127.0.0.1:52133",
+		"user":        "frank",
+		"request":     "GET /apache_pb.gif HTTP/1.0",
+		"status":      200,
+		"bytes":       2326,
+		"referer":     "http://www.example.com/start.html",
+		"user_agent":  `Mozilla/4.08 [en] (Win98; I ;Nav) "quoted"`,
+	}}
+	b, err := clfFormatter{}.Format(e)
+	if nil != err {
+		t.Fatal(err)
+	}
+	want := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08 [en] (Win98; I ;Nav) \"quoted\""` + "\n"
+	if string(b) != want {
+		t.Errorf("got  %s\nwant %s", b, want)
+	}
+
+	// middleware fields, the rest missing
+	e = &logrus.Entry{Time: at, Data: logrus.Fields{"method": "POST", "path": "/login", "status": 401}}
+	b, _ = clfFormatter{}.Format(e)
+	want = `- - - [10/Oct/2000:13:55:36 -0700] "POST /login" 401 - "-" "-"` + "\n"
+	if string(b) != want {
+		t.Errorf("got  %s\nwant %s", b, want)
+	}
+}
@@ -0,0 +1,52 @@
+package logx
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type entryKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying e, so request scoped
+// fields added along the call chain travel with the context.
+func ContextWithLogger(ctx context.Context, e *logrus.Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, e)
+}
+
+// FromContext returns the entry stored by ContextWithLogger, or an entry of
+// the stdout logger (with GetLogger's fallbacks) when there is none, bound
+// to ctx so it carries the correlation id of ctx.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if e, ok := ctx.Value(entryKey{}).(*logrus.Entry); ok && nil != e {
+		return e.WithContext(ctx)
+	}
+	return logrus.NewEntry(GetLogger("stdout")).WithContext(ctx)
+}
+
+type correlationKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying id. Entries of any
+// managed logger bound to that context, through FromContext or the
+// WithContext method of logrus, get it as a correlation_id field unless
+// they set one themselves.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// withCorrelation adds the correlation id of the entry context, on a copy
+func withCorrelation(e *logrus.Entry) *logrus.Entry {
+	if nil == e.Context {
+		return e
+	}
+	id, ok := e.Context.Value(correlationKey{}).(string)
+	if !ok || id == "" {
+		return e
+	}
+	if _, set := e.Data["correlation_id"]; set {
+		return e
+	}
+	e = cloneEntry(e)
+	e.Data["correlation_id"] = id
+	return e
+}
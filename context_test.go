@@ -0,0 +1,53 @@
+package logx
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestContextWithLogger(t *testing.T) {
+	defer Snapshot()()
+	l := logrus.New()
+	lock.Lock()
+	loggers["stdout"] = l
+	lock.Unlock()
+
+	if e := FromContext(context.Background()); e.Logger != l || len(e.Data) != 0 {
+		t.Error("fallback is not a bare stdout entry")
+	}
+
+	req := logrus.New().WithField("request_id", "r1")
+	ctx := ContextWithLogger(context.Background(), req)
+	ctx = ContextWithLogger(ctx, FromContext(ctx).WithField("user", "bob"))
+	e := FromContext(ctx)
+	if e.Data["request_id"] != "r1" || e.Data["user"] != "bob" {
+		t.Errorf("fields did not travel with the context: %v", e.Data)
+	}
+}
+
+func TestWithCorrelationID(t *testing.T) {
+	defer Snapshot()()
+	api, db := &syncBuffer{}, &syncBuffer{}
+	loggers["api"] = newTestLogger("api", api)
+	loggers["db"] = newTestLogger("db", db)
+
+	ctx := WithCorrelationID(context.Background(), "c-42")
+	ctx = ContextWithLogger(ctx, GetLogger("api").WithField("route", "/orders"))
+	FromContext(ctx).Info("handling")
+	GetLogger("db").WithContext(ctx).Info("querying")
+	GetLogger("db").WithContext(ctx).WithField("correlation_id", "own").Info("explicit")
+
+	if line := api.Lines()[0]; !strings.Contains(line, "correlation_id=c-42") || !strings.Contains(line, "route=/orders") {
+		t.Errorf("api line %q", line)
+	}
+	lines := db.Lines()
+	if !strings.Contains(lines[0], "correlation_id=c-42") {
+		t.Errorf("db line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "correlation_id=own") {
+		t.Errorf("explicit id replaced: %q", lines[1])
+	}
+}
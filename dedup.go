@@ -0,0 +1,99 @@
+package logx
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// dedupWindow is the collapse window in nanoseconds, 0 when disabled
+var dedupWindow int64
+
+// repeatCount marks the summary entry written for collapsed messages
+type repeatCount int
+
+// EnableDedup collapses identical consecutive messages (same level and
+// message) logged within window into the first line plus a single
+// "[repeated N times]" summary. The summary is written when a different
+// message arrives or when the window elapses. A zero window disables it.
+func EnableDedup(window time.Duration) {
+	atomic.StoreInt64(&dedupWindow, int64(window))
+}
+
+// dedupState tracks the last message seen by one output. emit, when set,
+// writes the summary of an elapsed window to that output alone; otherwise
+// it is logged through the logger, which only has the one output.
+type dedupState struct {
+	emit   func(*logrus.Entry)
+	mu     sync.Mutex
+	level  logrus.Level
+	msg    string
+	first  time.Time
+	count  int
+	gen    int
+	active bool
+}
+
+// filter reports whether e repeats the previous message and should be
+// dropped. If e ends a run of repeats the pending summary is returned.
+func (d *dedupState) filter(e *logrus.Entry) (drop bool, summary *logrus.Entry) {
+	window := time.Duration(atomic.LoadInt64(&dedupWindow))
+	if window <= 0 || e.Level <= logrus.FatalLevel {
+		return false, nil
+	}
+	if _, ok := e.Data["repeated"].(repeatCount); ok {
+		return false, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.active && e.Level == d.level && e.Message == d.msg && e.Time.Sub(d.first) < window {
+		d.count++
+		if d.count == 1 {
+			d.schedule(e.Logger, window-e.Time.Sub(d.first))
+		}
+		return true, nil
+	}
+
+	summary = d.summary(e.Logger)
+	d.level, d.msg, d.first, d.count, d.active = e.Level, e.Message, e.Time, 0, true
+	d.gen++
+	return false, summary
+}
+
+// schedule writes the summary once the window is over unless another
+// message has ended the run first
+func (d *dedupState) schedule(logger *logrus.Logger, after time.Duration) {
+	gen := d.gen
+	time.AfterFunc(after, func() {
+		d.mu.Lock()
+		if gen != d.gen {
+			d.mu.Unlock()
+			return
+		}
+		summary := d.summary(logger)
+		d.active = false
+		d.gen++
+		d.mu.Unlock()
+		switch {
+		case nil == summary:
+		case nil != d.emit:
+			d.emit(summary)
+		default:
+			summary.Log(summary.Level, summary.Message)
+		}
+	})
+}
+
+func (d *dedupState) summary(logger *logrus.Logger) *logrus.Entry {
+	if !d.active || d.count == 0 {
+		return nil
+	}
+	s := logger.WithField("repeated", repeatCount(d.count))
+	s.Level = d.level
+	s.Message = fmt.Sprintf("%s [repeated %d times]", d.msg, d.count)
+	return s
+}
@@ -0,0 +1,109 @@
+package logx
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// syncBuffer is a bytes.Buffer safe for the background writes some
+// features do
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Split(strings.TrimRight(b.buf.String(), "\n"), "\n")
+}
+
+func newTestLogger(tag string, out *syncBuffer) *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(out)
+	l.SetFormatter(newEntryFormatter(tag, &logrus.TextFormatter{DisableTimestamp: true}))
+	return l
+}
+
+func TestDedup(t *testing.T) {
+	EnableDedup(100 * time.Millisecond)
+	defer EnableDedup(0)
+
+	out := &syncBuffer{}
+	l := newTestLogger("dedup", out)
+	for i := 0; i < 5; i++ {
+		l.Error("boom")
+	}
+	l.Info("other")
+
+	lines := out.Lines()
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %q", len(lines), lines)
+	}
+	if !strings.Contains(lines[1], "boom [repeated 4 times]") || !strings.Contains(lines[1], "level=error") {
+		t.Errorf("missing summary at the message change: %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "other") {
+		t.Errorf("new message not written after summary: %q", lines[2])
+	}
+
+	// the summary is also written when the window elapses
+	l.Info("other")
+	l.Info("other")
+	if n := len(out.Lines()); n != 3 {
+		t.Fatalf("repeats written before the window elapsed: %d lines", n)
+	}
+	time.Sleep(200 * time.Millisecond)
+	lines = out.Lines()
+	if len(lines) != 4 || !strings.Contains(lines[3], "other [repeated 2 times]") {
+		t.Fatalf("summary not flushed after the window: %q", lines)
+	}
+
+	// after the flush the same message is written again
+	l.Info("other")
+	if n := len(out.Lines()); n != 5 {
+		t.Fatalf("message after flushed window was dropped: %d lines", n)
+	}
+}
+
+func TestDedupMultipleOutputs(t *testing.T) {
+	defer Snapshot()()
+	EnableDedup(50 * time.Millisecond)
+	defer EnableDedup(0)
+	dir := t.TempDir()
+	console, restore := redirectStdout(t)
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "m", Level: "info", Outputs: []OutputConfig{
+		{Type: "file"},
+		{Type: "console"},
+	}}})
+	restore()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	l := GetLogger("m")
+	for i := 0; i < 3; i++ {
+		l.Error("boom")
+	}
+	time.Sleep(150 * time.Millisecond)
+	Flush()
+	for _, name := range []string{path.Join(dir, "m.log"), console.Name()} {
+		content, _ := ioutil.ReadFile(name)
+		if n := strings.Count(string(content), "boom [repeated 2 times]"); n != 1 {
+			t.Errorf("%s has %d summaries, want 1: %q", name, n, content)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package logx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// DiskFullPolicy decides what file outputs do with a write that fails
+// because the disk holding the log path is full (ENOSPC)
+type DiskFullPolicy int32
+
+const (
+	// DiskFullError returns the write error, as without a policy
+	DiskFullError DiskFullPolicy = iota
+	// DiskFullConsole writes the line to stderr instead
+	DiskFullConsole
+	// DiskFullDrop discards the line, counted by DiskFullDropped
+	DiskFullDrop
+	// DiskFullFallback moves the output to a file of the same name in the
+	// fallback directory for the rest of its life
+	DiskFullFallback
+)
+
+func (p DiskFullPolicy) String() string {
+	switch p {
+	case DiskFullConsole:
+		return "console"
+	case DiskFullDrop:
+		return "drop"
+	case DiskFullFallback:
+		return "fallback"
+	}
+	return "error"
+}
+
+// diskFull is the current policy and diskFullDir its fallback directory;
+// diskFullDrops counts the lines discarded under any policy
+var diskFull int32
+var diskFullDir atomic.Value
+var diskFullDrops uint64
+
+// diskFullStderr is where DiskFullConsole writes, replaced by tests
+var diskFullStderr io.Writer = os.Stderr
+
+// SetDiskFullPolicy picks how file outputs degrade when their disk fills up,
+// so that a full disk costs log lines rather than blocking or failing the
+// service. dir is the fallback directory of DiskFullFallback and ignored by
+// the other policies. The policy applies to every file output from the next
+// failing write on.
+func SetDiskFullPolicy(p DiskFullPolicy, dir string) error {
+	switch p {
+	case DiskFullError, DiskFullConsole, DiskFullDrop:
+	case DiskFullFallback:
+		if dir == "" {
+			return errors.New("disk full policy fallback needs a directory")
+		}
+	default:
+		return fmt.Errorf("unknown disk full policy %d", p)
+	}
+	diskFullDir.Store(dir)
+	atomic.StoreInt32(&diskFull, int32(p))
+	return nil
+}
+
+// DiskFullDropped returns how many lines file outputs discarded so far
+// because their disk was full
+func DiskFullDropped() uint64 {
+	return atomic.LoadUint64(&diskFullDrops)
+}
+
+// isDiskFull reports whether err comes from a full disk
+func isDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
+
+// diskFullWriter applies the disk full policy to the file output out named
+// name; props are the properties the fallback file is opened with
+type diskFullWriter struct {
+	out   io.Writer
+	name  string
+	props map[string]string
+
+	mu       sync.Mutex
+	fallback io.Writer
+}
+
+func (d *diskFullWriter) Write(p []byte) (int, error) {
+	out := d.active()
+	if out != d.out {
+		return out.Write(p)
+	}
+
+	n, err := out.Write(p)
+	if nil == err || !isDiskFull(err) {
+		return n, err
+	}
+	switch DiskFullPolicy(atomic.LoadInt32(&diskFull)) {
+	case DiskFullConsole:
+		if _, cerr := diskFullStderr.Write(p); nil != cerr {
+			return n, err
+		}
+		return len(p), nil
+	case DiskFullDrop:
+		atomic.AddUint64(&diskFullDrops, 1)
+		return len(p), nil
+	case DiskFullFallback:
+		fallback, ferr := d.switchFallback()
+		if nil != ferr {
+			atomic.AddUint64(&diskFullDrops, 1)
+			return n, fmt.Errorf("%w (fallback: %v)", err, ferr)
+		}
+		return fallback.Write(p)
+	}
+	return n, err
+}
+
+// switchFallback opens the file of the same name in the fallback directory,
+// once
+func (d *diskFullWriter) switchFallback() (io.Writer, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if nil != d.fallback {
+		return d.fallback, nil
+	}
+	dir, _ := diskFullDir.Load().(string)
+	filename := filepath.Join(dir, filepath.Base(d.name))
+	if err := preflight(filename); nil != err {
+		return nil, err
+	}
+	w, err := fileLogWriter(filename, d.props)
+	if nil != err {
+		return nil, err
+	}
+	fmt.Fprintf(diskFullStderr, "logx: disk full writing %s, switching to %s\n", d.name, filename)
+	d.fallback = w
+	return w, nil
+}
+
+func (d *diskFullWriter) Close() error {
+	d.mu.Lock()
+	fallback := d.fallback
+	d.mu.Unlock()
+	var first error
+	if c, ok := d.out.(io.Closer); ok {
+		first = c.Close()
+	}
+	if c, ok := fallback.(io.Closer); ok {
+		if err := c.Close(); nil != err && nil == first {
+			first = err
+		}
+	}
+	return first
+}
+
+func (d *diskFullWriter) unwrap() io.Writer {
+	return d.active()
+}
+
+// active is the file currently written: the fallback once switched
+func (d *diskFullWriter) active() io.Writer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if nil != d.fallback {
+		return d.fallback
+	}
+	return d.out
+}
@@ -0,0 +1,83 @@
+package logx
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// fullDisk fails every write with a simulated ENOSPC
+type fullDisk struct{ writes int }
+
+func (f *fullDisk) Write(p []byte) (int, error) {
+	f.writes++
+	return 0, &os.PathError{Op: "write", Path: "full.log", Err: syscall.ENOSPC}
+}
+
+func TestDiskFullPolicy(t *testing.T) {
+	defer SetDiskFullPolicy(DiskFullError, "")
+	var stderr bytes.Buffer
+	diskFullStderr = &stderr
+	defer func() { diskFullStderr = os.Stderr }()
+
+	w := &diskFullWriter{out: &fullDisk{}, name: "full.log", props: map[string]string{}}
+	if _, err := w.Write([]byte("lost\n")); !isDiskFull(err) {
+		t.Fatalf("default policy: err = %v, want ENOSPC", err)
+	}
+
+	SetDiskFullPolicy(DiskFullConsole, "")
+	if n, err := w.Write([]byte("to console\n")); nil != err || n != 11 {
+		t.Fatalf("console policy: n = %d, err = %v", n, err)
+	}
+	if stderr.String() != "to console\n" {
+		t.Errorf("console policy wrote %q to stderr", stderr.String())
+	}
+
+	SetDiskFullPolicy(DiskFullDrop, "")
+	before := DiskFullDropped()
+	if _, err := w.Write([]byte("dropped\n")); nil != err {
+		t.Fatalf("drop policy: %v", err)
+	}
+	if got := DiskFullDropped() - before; got != 1 {
+		t.Errorf("drop policy counted %d drops, want 1", got)
+	}
+
+	dir, err := ioutil.TempDir("", "logx-diskfull")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := SetDiskFullPolicy(DiskFullFallback, dir); nil != err {
+		t.Fatal(err)
+	}
+	disk := &fullDisk{}
+	w = &diskFullWriter{out: disk, name: "/var/log/app/full.log", props: map[string]string{}}
+	for i := 0; i < 2; i++ {
+		if _, err := w.Write([]byte(fmt.Sprintf("line %d\n", i))); nil != err {
+			t.Fatalf("fallback policy: %v", err)
+		}
+	}
+	w.Close()
+	if disk.writes != 1 {
+		t.Errorf("full disk written %d times after the switch, want 1", disk.writes)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "full.log"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if string(b) != "line 0\nline 1\n" {
+		t.Errorf("fallback file = %q", b)
+	}
+	if !strings.Contains(stderr.String(), "switching to") {
+		t.Errorf("no switch notice on stderr: %q", stderr.String())
+	}
+
+	if err := SetDiskFullPolicy(DiskFullFallback, ""); nil == err {
+		t.Error("fallback without a directory accepted")
+	}
+}
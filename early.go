@@ -0,0 +1,178 @@
+package logx
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxEarlyEntries bounds the entries BufferEarlyLogs holds
+const maxEarlyEntries = 10000
+
+// early collects the entries logged before InitLogger once BufferEarlyLogs
+// is called; callers hold lock
+var early *earlyBuffer
+
+// BufferEarlyLogs holds the entries logged before InitLogger, through
+// GetLogger or the logrus standard logger, and replays them when
+// InitLogger succeeds, each through the logger then configured for its
+// tag, with its original time and fields. Loggers obtained before that
+// keep forwarding to the configured ones afterwards, so package level
+// loggers are routed too. At most 10000 entries are held; the rest are
+// dropped and counted in a warning after the replay. Held entries are
+// written to stderr when a logger from GetLogger exits the process on a
+// Fatal before InitLogger.
+func BufferEarlyLogs() {
+	lock.Lock()
+	defer lock.Unlock()
+	if nil != early {
+		return
+	}
+	early = &earlyBuffer{loggers: make(map[string]*logrus.Logger)}
+	logrus.AddHook(&earlyHook{b: early, std: true})
+}
+
+// earlyBuffer holds the entries logged before configuration and the loggers
+// GetLogger handed out meanwhile
+type earlyBuffer struct {
+	mu       sync.Mutex
+	entries  []earlyEntry
+	dropped  int
+	loggers  map[string]*logrus.Logger
+	replayed bool
+}
+
+// earlyEntry is a copy of an entry logged before configuration; std marks
+// those of the standard logger
+type earlyEntry struct {
+	tag     string
+	std     bool
+	level   logrus.Level
+	time    time.Time
+	message string
+	data    logrus.Fields
+}
+
+// logger returns the stand-in logger for tag, created on first use
+func (b *earlyBuffer) logger(tag string) *logrus.Logger {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if l, ok := b.loggers[tag]; ok {
+		return l
+	}
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetFormatter(discardFormatter{})
+	l.SetLevel(logrus.TraceLevel)
+	l.AddHook(&earlyHook{b: b, tag: tag})
+	l.ExitFunc = func(code int) {
+		b.dump(os.Stderr)
+		logrus.StandardLogger().ExitFunc(code)
+	}
+	b.loggers[tag] = l
+	return l
+}
+
+// earlyHook holds the entries of a stand-in logger, or of the standard
+// logger, until the replay and forwards them afterwards
+type earlyHook struct {
+	b   *earlyBuffer
+	tag string
+	std bool
+}
+
+func (h *earlyHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *earlyHook) Fire(e *logrus.Entry) error {
+	data := make(logrus.Fields, len(e.Data))
+	for k, v := range e.Data {
+		data[k] = v
+	}
+	entry := earlyEntry{tag: h.tag, std: h.std, level: e.Level, time: e.Time, message: e.Message, data: data}
+	h.b.mu.Lock()
+	if h.b.replayed {
+		h.b.mu.Unlock()
+		if !h.std {
+			entry.log()
+		}
+		return nil
+	}
+	if len(h.b.entries) < maxEarlyEntries {
+		h.b.entries = append(h.b.entries, entry)
+	} else {
+		h.b.dropped++
+	}
+	h.b.mu.Unlock()
+	return nil
+}
+
+// log sends e through the logger now configured for its tag
+func (e earlyEntry) log() {
+	target := logrus.StandardLogger()
+	if !e.std {
+		target = GetLogger(e.tag)
+	}
+	defer func() {
+		// Log panics for Panic entries, which were logged already
+		if e.level != logrus.PanicLevel {
+			return
+		}
+		recover()
+	}()
+	target.WithFields(e.data).WithTime(e.time).Log(e.level, e.message)
+}
+
+// replay logs the held entries in order, after InitLogger released lock
+func (b *earlyBuffer) replay() {
+	b.mu.Lock()
+	entries, dropped := b.entries, b.dropped
+	b.entries, b.replayed = nil, true
+	b.mu.Unlock()
+	for _, e := range entries {
+		e.log()
+	}
+	if dropped > 0 {
+		logrus.StandardLogger().WithField("dropped", dropped).Warn("early log buffer full, entries dropped")
+	}
+}
+
+// dump writes the held entries to w, for a Fatal before InitLogger
+func (b *earlyBuffer) dump(w io.Writer) {
+	b.mu.Lock()
+	entries := b.entries
+	b.entries = nil
+	b.mu.Unlock()
+	for _, e := range entries {
+		entry := logrus.NewEntry(logrus.StandardLogger()).WithFields(e.data).WithTime(e.time)
+		entry.Level, entry.Message = e.level, e.message
+		if p, err := txtFormatter.Format(entry); nil == err {
+			w.Write(p)
+		}
+	}
+}
+
+// endEarly stops buffering and returns the buffer to replay; callers hold
+// lock
+func endEarly() *earlyBuffer {
+	b := early
+	if nil == b {
+		return nil
+	}
+	early = nil
+	std := logrus.StandardLogger()
+	kept := make(logrus.LevelHooks, len(std.Hooks))
+	for level, list := range std.Hooks {
+		for _, h := range list {
+			if eh, ok := h.(*earlyHook); !ok || eh.b != b {
+				kept[level] = append(kept[level], h)
+			}
+		}
+	}
+	std.ReplaceHooks(kept)
+	return b
+}
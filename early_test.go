@@ -0,0 +1,48 @@
+package logx
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestBufferEarlyLogs(t *testing.T) {
+	defer Snapshot()()
+	BufferEarlyLogs()
+	pkgLogger := GetLogger("boot")
+	pkgLogger.WithField("phase", "flags").Info("parsed flags")
+	GetLogger("boot").Debug("below the configured level")
+
+	dir := t.TempDir()
+	if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "boot", Type: "file", Level: "info"}}); nil != err {
+		t.Fatal(err)
+	}
+	defer Close()
+	pkgLogger.Info("after init")
+	Flush()
+
+	b, err := ioutil.ReadFile(path.Join(dir, "boot.log"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	content := string(b)
+	if !strings.Contains(content, "parsed flags") || !strings.Contains(content, "phase=flags") {
+		t.Errorf("early line missing from the configured file:\n%s", content)
+	}
+	if !strings.Contains(content, "after init") {
+		t.Errorf("logger taken before init not forwarded:\n%s", content)
+	}
+	if strings.Contains(content, "below the configured level") {
+		t.Errorf("replay ignored the configured level:\n%s", content)
+	}
+	if strings.Index(content, "parsed flags") > strings.Index(content, "after init") {
+		t.Errorf("early line replayed out of order:\n%s", content)
+	}
+	lock.RLock()
+	still := nil != early
+	lock.RUnlock()
+	if still {
+		t.Error("still buffering after init")
+	}
+}
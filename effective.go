@@ -0,0 +1,119 @@
+package logx
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// configs are the normalized filters of the managed loggers, by tag
+var configs = make(map[string]FilterConfig)
+
+// fileDefaults are the properties a file output falls back to
+var fileDefaults = map[string]string{
+	"maxsize":      "100M",
+	"maxbackups":   "10",
+	"rotationtime": "1h",
+}
+
+// EffectiveConfig returns the filters of the loggers configured by
+// InitLogger, sorted by tag, normalized so they can be compared with a
+// freshly parsed file: levels are spelled the logrus way, forward
+// properties are expanded into outputs, and file and network outputs list
+// the defaults they run with. The default stdout and stderr loggers are
+// left out unless configured.
+func EffectiveConfig() []FilterConfig {
+	lock.RLock()
+	defer lock.RUnlock()
+	tags := make([]string, 0, len(configs))
+	for tag := range configs {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	effective := make([]FilterConfig, 0, len(tags))
+	for _, tag := range tags {
+		effective = append(effective, copyFilter(configs[tag]))
+	}
+	return effective
+}
+
+// normalizeFilter fills in the defaults of fc, on a copy
+func normalizeFilter(fc FilterConfig) FilterConfig {
+	if expanded, err := expandForward(fc); nil == err {
+		fc = expanded
+	}
+	fc = copyFilter(fc)
+	fc.Level = normalizeLevel(fc.Level)
+	if len(fc.Outputs) == 0 {
+		fillOutputDefaults(fc.Type, fc.Properties)
+	}
+	for i := range fc.Outputs {
+		oc := &fc.Outputs[i]
+		if oc.Level != "" {
+			oc.Level = normalizeLevel(oc.Level)
+		}
+		// filter properties apply to the outputs, leaving only the others
+		// to fill in
+		merged := make(map[string]string, len(fc.Properties))
+		for k, v := range fc.Properties {
+			merged[k] = v
+		}
+		for k, v := range oc.Properties {
+			merged[k] = v
+		}
+		fillOutputDefaults(oc.Type, merged)
+		for k, v := range merged {
+			if _, ok := fc.Properties[k]; !ok {
+				oc.Properties[k] = v
+			}
+		}
+	}
+	return fc
+}
+
+func fillOutputDefaults(typ string, props map[string]string) {
+	switch typ {
+	case "file":
+		for k, v := range fileDefaults {
+			if _, ok := props[k]; !ok {
+				props[k] = v
+			}
+		}
+	case "network":
+		if _, ok := props["network"]; !ok {
+			props["network"] = "tcp"
+		}
+	}
+}
+
+func normalizeLevel(v string) string {
+	if level, err := logrus.ParseLevel(strings.TrimSpace(v)); nil == err {
+		return level.String()
+	}
+	return v
+}
+
+// copyFilter deep copies the property maps and outputs of fc
+func copyFilter(fc FilterConfig) FilterConfig {
+	fc.Properties = copyProps(fc.Properties)
+	outputs := make([]OutputConfig, len(fc.Outputs))
+	for i, oc := range fc.Outputs {
+		oc.Properties = copyProps(oc.Properties)
+		outputs[i] = oc
+	}
+	if len(outputs) > 0 {
+		fc.Outputs = outputs
+	} else {
+		fc.Outputs = nil
+	}
+	return fc
+}
+
+func copyProps(props map[string]string) map[string]string {
+	c := make(map[string]string, len(props))
+	for k, v := range props {
+		c[k] = v
+	}
+	return c
+}
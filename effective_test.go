@@ -0,0 +1,34 @@
+package logx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEffectiveConfig(t *testing.T) {
+	defer Snapshot()()
+	configs = map[string]FilterConfig{}
+	err := InitLoggerFromFilters(t.TempDir(), []FilterConfig{
+		{Tag: "sized", Type: "file", Level: "WARNING", Properties: map[string]string{"maxsize": "1G"}},
+		{Tag: "plain", Type: "file", Level: "info"},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	want := []FilterConfig{
+		{Tag: "plain", Type: "file", Level: "info", Properties: map[string]string{
+			"maxsize": "100M", "maxbackups": "10", "rotationtime": "1h"}},
+		{Tag: "sized", Type: "file", Level: "warning", Properties: map[string]string{
+			"maxsize": "1G", "maxbackups": "10", "rotationtime": "1h"}},
+	}
+	got := EffectiveConfig()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EffectiveConfig() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// callers get copies
+	got[0].Properties["maxsize"] = "1K"
+	if EffectiveConfig()[0].Properties["maxsize"] != "100M" {
+		t.Error("EffectiveConfig shares its maps")
+	}
+}
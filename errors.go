@@ -0,0 +1,37 @@
+package logx
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// errorChain is 1 when Error adds the unwrapped chain of its error
+var errorChain int32
+
+// EnableErrorChain makes Error also attach an error_chain field listing
+// the messages of err and of every error it wraps, outermost first.
+func EnableErrorChain(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&errorChain, v)
+}
+
+// Error logs msg at Error level with err attached through the logger
+// GetLogger resolves for tag; it is short for
+//
+//	logx.GetLogger(tag).WithError(err).Error(msg)
+func Error(tag string, err error, msg string) {
+	e := GetLogger(tag).WithError(err)
+	if atomic.LoadInt32(&errorChain) == 1 && nil != err {
+		var chain []string
+		for ; nil != err; err = errors.Unwrap(err) {
+			chain = append(chain, err.Error())
+		}
+		e = e.WithField("error_chain", chain)
+	}
+	e.Log(logrus.ErrorLevel, msg)
+}
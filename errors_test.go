@@ -0,0 +1,35 @@
+package logx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	defer Snapshot()()
+	out := &syncBuffer{}
+	lock.Lock()
+	loggers["errors"] = newTestLogger("errors", out)
+	lock.Unlock()
+
+	cause := errors.New("disk full")
+	err := fmt.Errorf("write segment: %w", cause)
+	Error("errors", err, "flush failed")
+
+	EnableErrorChain(true)
+	defer EnableErrorChain(false)
+	Error("errors", err, "flush failed again")
+
+	lines := out.Lines()
+	if !strings.Contains(lines[0], `level=error msg="flush failed" error="write segment: disk full"`) {
+		t.Errorf("error field not attached: %q", lines[0])
+	}
+	if strings.Contains(lines[0], "error_chain") {
+		t.Errorf("chain attached while disabled: %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `error_chain="[write segment: disk full disk full]"`) {
+		t.Errorf("chain not attached: %q", lines[1])
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package logx
+
+import (
+	"errors"
+	"io"
+)
+
+// eventLogOutput is only available on Windows
+func eventLogOutput(props map[string]string) (io.Writer, error) {
+	return nil, errors.New("eventlog output is only supported on windows")
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package logx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEventLogUnsupported(t *testing.T) {
+	defer Snapshot()()
+	err := InitLoggerFromFilters(t.TempDir(), []FilterConfig{{Tag: "events", Type: "eventlog", Level: "info",
+		Properties: map[string]string{"source": "logx"}}})
+	if nil == err || !strings.Contains(err.Error(), "only supported on windows") {
+		t.Fatalf("expected an unsupported error, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build windows
+// +build windows
+
+package logx
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventID is the event identifier every entry is reported with
+const eventID = 1
+
+// eventLogWriter reports entries to the Windows Event Log
+type eventLogWriter struct {
+	log *eventlog.Log
+}
+
+// eventLogOutput opens the event log for the source property. The source has
+// to be registered beforehand, which needs administrator rights and is
+// usually done once by the installer, e.g. with
+// eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info).
+func eventLogOutput(props map[string]string) (io.Writer, error) {
+	source := props["source"]
+	if source == "" {
+		return nil, errors.New("eventlog output needs a source property")
+	}
+	l, err := eventlog.Open(source)
+	if nil != err {
+		return nil, err
+	}
+	return &eventLogWriter{log: l}, nil
+}
+
+func (w *eventLogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(logrus.InfoLevel, p)
+}
+
+// WriteLevel maps Error and above to error events, Warn to warning events
+// and everything else to information events
+func (w *eventLogWriter) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\n")
+	var err error
+	switch {
+	case level <= logrus.ErrorLevel:
+		err = w.log.Error(eventID, msg)
+	case level == logrus.WarnLevel:
+		err = w.log.Warning(eventID, msg)
+	default:
+		err = w.log.Info(eventID, msg)
+	}
+	if nil != err {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *eventLogWriter) Close() error {
+	return w.log.Close()
+}
@@ -0,0 +1,68 @@
+package logx
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxEveryKeys bounds the keys LogEvery remembers
+const maxEveryKeys = 4096
+
+var everyLock sync.Mutex
+var everyNext = make(map[string]time.Time) // by tag and key, when it may log again
+
+// quiet is the logger of the entries LogEvery hands out when throttled
+var quiet = &logrus.Logger{
+	Out:       io.Discard,
+	Formatter: discardFormatter{},
+	Hooks:     make(logrus.LevelHooks),
+	Level:     logrus.PanicLevel,
+	// SetExitFunc applies to the standard logger too
+	ExitFunc: func(code int) { logrus.StandardLogger().ExitFunc(code) },
+}
+
+// LogEvery returns an entry of the logger named tag when key has not been
+// logged through LogEvery within every, and otherwise an entry that logs
+// nothing, for warnings wanted at most once a minute however often they
+// fire:
+//
+//	logx.LogEvery("db", "pool-exhausted", time.Minute).Warn("connection pool exhausted")
+//
+// The window starts when the entry is handed out. Fatal and Panic on a
+// throttled entry still exit and panic. At most 4096 keys are remembered,
+// expired ones being forgotten first.
+func LogEvery(tag string, key string, every time.Duration) *logrus.Entry {
+	now := time.Now()
+	k := tag + "\x00" + key
+	everyLock.Lock()
+	if next, ok := everyNext[k]; ok && now.Before(next) {
+		everyLock.Unlock()
+		return logrus.NewEntry(quiet)
+	}
+	if len(everyNext) >= maxEveryKeys {
+		forgetEvery(now)
+	}
+	everyNext[k] = now.Add(every)
+	everyLock.Unlock()
+	return logrus.NewEntry(GetLogger(tag))
+}
+
+// forgetEvery makes room in everyNext: expired keys go, then arbitrary ones
+// down to 7/8 of the bound so the sweep is not repeated on every call;
+// callers hold everyLock
+func forgetEvery(now time.Time) {
+	for k, next := range everyNext {
+		if !now.Before(next) {
+			delete(everyNext, k)
+		}
+	}
+	for k := range everyNext {
+		if len(everyNext) < maxEveryKeys*7/8 {
+			break
+		}
+		delete(everyNext, k)
+	}
+}
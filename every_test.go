@@ -0,0 +1,46 @@
+package logx
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestLogEvery(t *testing.T) {
+	defer Snapshot()()
+	t.Cleanup(func() {
+		everyLock.Lock()
+		everyNext = make(map[string]time.Time)
+		everyLock.Unlock()
+	})
+	if err := InitLoggerFromFilters(t.TempDir(), []FilterConfig{{Tag: "every-test", Type: "file", Level: "info"}}); nil != err {
+		t.Fatal(err)
+	}
+	hook := test.NewLocal(GetLogger("every-test"))
+
+	for i := 0; i < 10; i++ {
+		LogEvery("every-test", "pool", 50*time.Millisecond).Warn("pool exhausted")
+		LogEvery("every-test", "disk", time.Hour).Warn("disk slow")
+	}
+	if n := len(hook.AllEntries()); n != 2 {
+		t.Fatalf("%d entries from 10 calls on 2 keys within the window", n)
+	}
+	time.Sleep(60 * time.Millisecond)
+	LogEvery("every-test", "pool", 50*time.Millisecond).Warn("pool exhausted")
+	LogEvery("every-test", "disk", time.Hour).Warn("disk slow")
+	if n := len(hook.AllEntries()); n != 3 {
+		t.Errorf("%d entries, want the pool warning again after its window", n)
+	}
+
+	for i := 0; i < 2*maxEveryKeys; i++ {
+		LogEvery("every-test", fmt.Sprint("key", i), time.Hour)
+	}
+	everyLock.Lock()
+	n := len(everyNext)
+	everyLock.Unlock()
+	if n > maxEveryKeys {
+		t.Errorf("%d keys remembered, bound is %d", n, maxEveryKeys)
+	}
+}
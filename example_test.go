@@ -0,0 +1,19 @@
+package logx_test
+
+import (
+	"github.com/blackbeans/logx"
+)
+
+func ExampleInitLoggerFromFilters() {
+	err := logx.InitLoggerFromFilters("./logs", []logx.FilterConfig{
+		{Tag: "console", Type: "console", Level: "debug"},
+		{Tag: "access", Type: "file", Level: "info", Properties: map[string]string{
+			"maxsize":    "200M",
+			"maxbackups": "24",
+		}},
+	})
+	if nil != err {
+		panic(err)
+	}
+	logx.GetLogger("access").Info("service started")
+}
@@ -0,0 +1,74 @@
+package logx
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lockName is the lock file SetExclusive creates in the log path
+const lockName = ".logx.lock"
+
+// exclusive makes InitLogger lock its log path; pathLocks holds the locks
+// taken, by cleaned path, until Close
+var exclusive bool
+var pathLocks = make(map[string]*os.File)
+
+// SetExclusive makes InitLogger take an exclusive lock on a .logx.lock file
+// in the log path, holding the pid of the owner, and fail when another
+// process holds it, so that two processes never write and rotate the same
+// files. The lock is released by Close.
+func SetExclusive(enabled bool) {
+	lock.Lock()
+	defer lock.Unlock()
+	exclusive = enabled
+}
+
+// lockPath locks logPath for this process; callers hold lock
+func lockPath(logPath string) error {
+	dir := filepath.Clean(logPath)
+	if _, ok := pathLocks[dir]; ok {
+		return nil
+	}
+	f, err := tryLock(dir)
+	if nil != err {
+		return err
+	}
+	pathLocks[dir] = f
+	return nil
+}
+
+// tryLock takes the lock of dir and records our pid in it
+func tryLock(dir string) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0755); nil != err {
+		return nil, err
+	}
+	name := filepath.Join(dir, lockName)
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0644)
+	if nil != err {
+		return nil, err
+	}
+	if err := lockFile(f); nil != err {
+		owner, _ := ioutil.ReadAll(f)
+		f.Close()
+		if pid := strings.TrimSpace(string(owner)); pid != "" {
+			return nil, fmt.Errorf("log path %s is in use by pid %s: %w", dir, pid, err)
+		}
+		return nil, fmt.Errorf("log path %s is in use: %w", dir, err)
+	}
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return f, nil
+}
+
+// unlockPaths releases every path lock; callers hold lock
+func unlockPaths() {
+	for dir, f := range pathLocks {
+		unlockFile(f)
+		f.Close()
+		delete(pathLocks, dir)
+	}
+}
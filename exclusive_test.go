@@ -0,0 +1,42 @@
+package logx
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSetExclusive(t *testing.T) {
+	defer Snapshot()()
+	SetExclusive(true)
+	defer SetExclusive(false)
+	dir := t.TempDir()
+	filters := []FilterConfig{{Tag: "only-me", Type: "file", Level: "info"}}
+
+	// another process holding the path
+	other, err := tryLock(dir)
+	if nil != err {
+		t.Fatal(err)
+	}
+	err = InitLoggerFromFilters(dir, filters)
+	if nil == err || !strings.Contains(err.Error(), "in use by pid "+strconv.Itoa(os.Getpid())) {
+		t.Errorf("second writer got %v", err)
+	}
+	unlockFile(other)
+	other.Close()
+
+	if err := InitLoggerFromFilters(dir, filters); nil != err {
+		t.Fatal(err)
+	}
+	if _, err := tryLock(dir); nil == err {
+		t.Error("lock not held after InitLogger")
+	}
+	Close()
+	f, err := tryLock(dir)
+	if nil != err {
+		t.Fatalf("lock not released by Close: %v", err)
+	}
+	unlockFile(f)
+	f.Close()
+}
@@ -0,0 +1,88 @@
+package logx
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// failovers counts the writes fallback files took over
+var failovers uint64
+
+// Failovers returns how many writes went to a fallback file because their
+// primary output failed, across all outputs
+func Failovers() uint64 {
+	return atomic.LoadUint64(&failovers)
+}
+
+// fallbackOutput puts out in front of a rotating fallback file when the
+// fallbackfile property is set: true names it tag.fallback.log, any other
+// value is a file name relative to logPath. Only failures out reports
+// synchronously fail over, so a queued or buffered output takes its
+// errors with it; console outputs and those fed by hooks ignore the
+// property.
+func fallbackOutput(out io.Writer, logPath string, tag string, props map[string]string) (io.Writer, error) {
+	v := strings.TrimSpace(props["fallbackfile"])
+	name := v
+	if on, err := strconv.ParseBool(v); nil == err {
+		if !on {
+			return out, nil
+		}
+		name = tag + ".fallback.log"
+	}
+	if name == "" {
+		return out, nil
+	}
+	switch out.(type) {
+	case levelWriter, entryWriter:
+		return out, nil
+	}
+	if out == io.Writer(os.Stdout) || out == io.Writer(os.Stderr) {
+		return out, nil
+	}
+	filename := path.Join(logPath, name)
+	if err := preflight(filename); nil != err {
+		return nil, fmt.Errorf("%s: fallbackfile: %w", tag, err)
+	}
+	fallback, err := fileLogWriter(filename, props)
+	if nil != err {
+		return nil, fmt.Errorf("%s: fallbackfile: %w", tag, err)
+	}
+	return &failoverWriter{primary: out, fallback: fallback}, nil
+}
+
+// failoverWriter writes to primary, and to fallback whatever primary fails
+// to take
+type failoverWriter struct {
+	primary  io.Writer
+	fallback io.Writer
+}
+
+func (f *failoverWriter) Write(p []byte) (int, error) {
+	n, err := f.primary.Write(p)
+	if nil == err {
+		return n, nil
+	}
+	atomic.AddUint64(&failovers, 1)
+	if _, ferr := f.fallback.Write(p); nil != ferr {
+		return n, fmt.Errorf("%w (fallback: %v)", err, ferr)
+	}
+	return len(p), nil
+}
+
+// unwrap is the primary output; the fallback only takes what it fails
+func (f *failoverWriter) unwrap() io.Writer {
+	return f.primary
+}
+
+func (f *failoverWriter) Flush() error {
+	return outputGroup{f.primary, f.fallback}.Flush()
+}
+
+func (f *failoverWriter) Close() error {
+	return outputGroup{f.primary, f.fallback}.Close()
+}
@@ -0,0 +1,41 @@
+package logx
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"path"
+	"testing"
+)
+
+// downWriter fails every write, like a sink that is down
+type downWriter struct{}
+
+func (downWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection refused")
+}
+
+func TestFallbackFile(t *testing.T) {
+	dir := t.TempDir()
+	w, err := fallbackOutput(downWriter{}, dir, "audit", map[string]string{"fallbackfile": "true"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	before := Failovers()
+	if n, err := w.Write([]byte("kept\n")); nil != err || n != 5 {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	w.(io.Closer).Close()
+	if got := Failovers() - before; got != 1 {
+		t.Errorf("%d failovers counted, want 1", got)
+	}
+	b, err := ioutil.ReadFile(path.Join(dir, "audit.fallback.log"))
+	if nil != err || string(b) != "kept\n" {
+		t.Errorf("fallback file = %q, %v", b, err)
+	}
+
+	out := &syncBuffer{}
+	if w, _ := fallbackOutput(out, dir, "audit", map[string]string{}); w != io.Writer(out) {
+		t.Error("output wrapped without fallbackfile")
+	}
+}
@@ -0,0 +1,150 @@
+package logx
+
+import (
+	"os"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultFieldsHook adds fixed fields to every entry that does not already
+// carry them
+type defaultFieldsHook struct {
+	fields logrus.Fields
+}
+
+func (h *defaultFieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *defaultFieldsHook) Fire(e *logrus.Entry) error {
+	for k, v := range h.fields {
+		if _, ok := e.Data[k]; !ok {
+			e.Data[k] = v
+		}
+	}
+	return nil
+}
+
+// defaultFields holds the logrus.Fields added to every entry of the managed
+// loggers; the map is replaced, never modified
+var defaultFields atomic.Value
+
+// addDefaultFields adds fields to those of every entry
+func addDefaultFields(fields logrus.Fields) {
+	lock.Lock()
+	defer lock.Unlock()
+	old, _ := defaultFields.Load().(logrus.Fields)
+	merged := make(logrus.Fields, len(old)+len(fields))
+	for k, v := range old {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	defaultFields.Store(merged)
+}
+
+// withDefaultFields adds the default fields e does not carry, on a copy
+// when there are any
+func withDefaultFields(e *logrus.Entry) *logrus.Entry {
+	fields, _ := defaultFields.Load().(logrus.Fields)
+	copied := false
+	for k, v := range fields {
+		if _, ok := e.Data[k]; ok {
+			continue
+		}
+		if !copied {
+			e, copied = cloneEntry(e), true
+		}
+		e.Data[k] = v
+	}
+	return e
+}
+
+// EnableK8sFields attaches pod, namespace and node fields to every entry of
+// the managed loggers, read once from the downward API variables POD_NAME,
+// POD_NAMESPACE and NODE_NAME. Unset variables are skipped, so outside
+// Kubernetes nothing is installed.
+func EnableK8sFields() {
+	fields := logrus.Fields{}
+	for field, env := range map[string]string{
+		"pod":       "POD_NAME",
+		"namespace": "POD_NAMESPACE",
+		"node":      "NODE_NAME",
+	} {
+		if v := os.Getenv(env); v != "" {
+			fields[field] = v
+		}
+	}
+	if len(fields) > 0 {
+		addDefaultFields(fields)
+	}
+}
+
+// SetVersionFields attaches version and commit fields, such as the build
+// version and VCS revision, to every entry of the managed loggers. Call it
+// once at startup; fields the caller sets of the same name win, and empty
+// values are skipped.
+func SetVersionFields(version, commit string) {
+	fields := logrus.Fields{}
+	if version != "" {
+		fields["version"] = version
+	}
+	if commit != "" {
+		fields["commit"] = commit
+	}
+	if len(fields) > 0 {
+		addDefaultFields(fields)
+	}
+}
+
+// fieldFilter prunes the fields of the entries of one output: with include
+// set only those fields are kept, otherwise the exclude ones are dropped
+type fieldFilter struct {
+	include map[string]bool
+	exclude map[string]bool
+}
+
+// configureFields applies the includefields and excludefields properties,
+// comma separated field names, to the entryFormatter f
+func configureFields(f logrus.Formatter, props map[string]string) {
+	ef, ok := f.(*entryFormatter)
+	if !ok {
+		return
+	}
+	toSet := func(v string) map[string]bool {
+		names := splitList(v)
+		if len(names) == 0 {
+			return nil
+		}
+		set := make(map[string]bool, len(names))
+		for _, name := range names {
+			set[name] = true
+		}
+		return set
+	}
+	ef.fields = fieldFilter{include: toSet(props["includefields"]), exclude: toSet(props["excludefields"])}
+}
+
+// prune drops the filtered fields of e, on a copy when there are any
+func (f fieldFilter) prune(e *logrus.Entry) *logrus.Entry {
+	if nil == f.include && nil == f.exclude {
+		return e
+	}
+	copied := false
+	for k := range e.Data {
+		keep := f.include[k]
+		if nil == f.include {
+			keep = !f.exclude[k]
+		}
+		if keep {
+			continue
+		}
+		if !copied {
+			e, copied = cloneEntry(e), true
+		}
+		delete(e.Data, k)
+	}
+	return e
+}
@@ -0,0 +1,138 @@
+package logx
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestK8sFields(t *testing.T) {
+	defer Snapshot()()
+	t.Setenv("POD_NAME", "api-7f9c")
+	t.Setenv("POD_NAMESPACE", "prod")
+	t.Setenv("NODE_NAME", "")
+	dir := t.TempDir()
+	console, restore := redirectStdout(t)
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "k8s", Level: "info", Outputs: []OutputConfig{
+		{Type: "file"},
+		{Type: "console"},
+	}}})
+	restore()
+	if nil != err {
+		t.Fatal(err)
+	}
+	EnableK8sFields()
+
+	GetLogger("k8s").WithField("pod", "override").Info("hello")
+	Flush()
+	for _, name := range []string{path.Join(dir, "k8s.log"), console.Name()} {
+		content, _ := ioutil.ReadFile(name)
+		if !strings.Contains(string(content), "namespace=prod") || !strings.Contains(string(content), "pod=override") {
+			t.Errorf("%s: unexpected fields %q", name, content)
+		}
+		if strings.Contains(string(content), "node=") {
+			t.Errorf("%s: unset NODE_NAME produced a field: %q", name, content)
+		}
+	}
+}
+
+func TestPrefixProperty(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "db", Type: "console", Level: "info", Properties: map[string]string{"prefix": "db"}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	out := &syncBuffer{}
+	l := GetLogger("db")
+	l.SetOutput(out)
+	l.Info("connected")
+	l.WithField("prefix", "db.pool").Info("resized")
+
+	lines := out.Lines()
+	if !strings.Contains(lines[0], " INFO db: connected") {
+		t.Errorf("prefix not rendered: %q", lines[0])
+	}
+	if !strings.Contains(lines[1], " INFO db.pool: resized") {
+		t.Errorf("entry prefix not kept: %q", lines[1])
+	}
+}
+
+func TestSetVersionFields(t *testing.T) {
+	defer Snapshot()()
+	SetVersionFields("1.4.2", "")
+
+	out := &syncBuffer{}
+	l := newLogger("versioned", logrus.InfoLevel, &logrus.TextFormatter{DisableTimestamp: true})
+	l.SetOutput(out)
+	l.Info("started")
+	l.WithField("version", "caller").Info("overridden")
+
+	lines := out.Lines()
+	if !strings.Contains(lines[0], "version=1.4.2") || strings.Contains(lines[0], "commit=") {
+		t.Errorf("first line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "version=caller") {
+		t.Errorf("caller field replaced: %q", lines[1])
+	}
+}
+
+func TestVersionFieldsMultipleOutputs(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	console, restore := redirectStdout(t)
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "m", Level: "info", Outputs: []OutputConfig{
+		{Type: "file"},
+		{Type: "console"},
+	}}})
+	restore()
+	if nil != err {
+		t.Fatal(err)
+	}
+	SetVersionFields("v1.2", "abc")
+
+	GetLogger("m").Info("started")
+	Flush()
+	for _, name := range []string{path.Join(dir, "m.log"), console.Name()} {
+		content, _ := ioutil.ReadFile(name)
+		if !strings.Contains(string(content), "version=v1.2") || !strings.Contains(string(content), "commit=abc") {
+			t.Errorf("%s lacks the version fields: %q", name, content)
+		}
+	}
+}
+
+func TestIncludeExcludeFields(t *testing.T) {
+	dir := t.TempDir()
+	built, closeOutputs, err := BuildLoggers(dir, []FilterConfig{
+		{Tag: "allow", Type: "file", Level: "info", Properties: map[string]string{"includefields": "user, route"}},
+		{Tag: "deny", Type: "file", Level: "info", Properties: map[string]string{"excludefields": "internal_id,debug_blob"}},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	fields := logrus.Fields{"user": "ann", "route": "/orders", "region": "eu", "internal_id": 7, "debug_blob": "xyz"}
+	for _, tag := range []string{"allow", "deny"} {
+		e := built[tag].WithFields(fields)
+		e.Info("request")
+		if len(e.Data) != 5 {
+			t.Errorf("%s: the entry itself was pruned", tag)
+		}
+	}
+	closeOutputs()
+
+	for tag, want := range map[string][]string{"allow": {"user=ann", "route=/orders"}, "deny": {"user=ann", "route=/orders", "region=eu"}} {
+		contents, _ := ioutil.ReadFile(path.Join(dir, tag+".log"))
+		line := string(contents)
+		for _, w := range want {
+			if !strings.Contains(line, w) {
+				t.Errorf("%s: %q lacks %s", tag, line, w)
+			}
+		}
+		if strings.Contains(line, "internal_id") || strings.Contains(line, "debug_blob") || (tag == "allow" && strings.Contains(line, "region")) {
+			t.Errorf("%s: %q kept filtered fields", tag, line)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package logx
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	prefixed "github.com/x-cray/logrus-prefixed-formatter"
+)
+
+// textFormatter renders the same layout as the prefixed formatter but lets
+// the caller decide the order of the fields: FieldOrder comes first, the
+// remaining fields follow sorted by name. With MessageKey set the message is
+// written as a key=value pair instead of bare text.
+type textFormatter struct {
+	TimestampFormat string
+	FieldOrder      []string
+	MessageKey      string
+}
+
+func (f *textFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b := entry.Buffer
+	if nil == b {
+		b = &bytes.Buffer{}
+	}
+
+	level := entry.Level.String()
+	if entry.Level == logrus.WarnLevel {
+		level = "warn"
+	}
+	fmt.Fprintf(b, "[%s] %5s", entry.Time.Format(f.TimestampFormat), strings.ToUpper(level))
+	if prefix, ok := entry.Data["prefix"]; ok {
+		fmt.Fprintf(b, " %v:", prefix)
+	}
+	b.WriteByte(' ')
+	if f.MessageKey == "" {
+		b.WriteString(entry.Message)
+	} else if strings.ContainsAny(entry.Message, " =\"\n") {
+		fmt.Fprintf(b, "%s=%q", f.MessageKey, entry.Message)
+	} else {
+		fmt.Fprintf(b, "%s=%s", f.MessageKey, entry.Message)
+	}
+
+	for _, k := range f.keys(entry.Data) {
+		fmt.Fprintf(b, " %s=%+v", k, entry.Data[k])
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func (f *textFormatter) keys(data logrus.Fields) []string {
+	keys := make([]string, 0, len(data))
+	seen := make(map[string]bool, len(f.FieldOrder))
+	for _, k := range f.FieldOrder {
+		if _, ok := data[k]; ok && !seen[k] {
+			keys = append(keys, k)
+		}
+		seen[k] = true
+	}
+
+	rest := make([]string, 0, len(data))
+	for k := range data {
+		if !seen[k] && k != "prefix" {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// filterFormatter picks the formatter for a filter from its properties:
+// format selects "json", "proto", "clf" (the Apache combined log format),
+// "auto" (colored text on a terminal, compact logfmt elsewhere) or the
+// default text layout, messagekey renames the message key and
+// fieldorder orders text fields. colors overrides the level colors of the
+// default layout and forcecolors turns them on when the output is not a
+// terminal. format.<level>, such as
+// format.error=json, picks another format for the entries of one level.
+func filterFormatter(props map[string]string) (logrus.Formatter, error) {
+	byLevel := map[logrus.Level]string{}
+	for k, v := range props {
+		if !strings.HasPrefix(k, "format.") {
+			continue
+		}
+		level, err := logrus.ParseLevel(strings.TrimPrefix(k, "format."))
+		if nil != err {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		byLevel[level] = v
+	}
+	if len(byLevel) == 0 {
+		return baseFormatter(props)
+	}
+
+	// format.<level> overrides format for the entries of that level
+	base := make(map[string]string, len(props))
+	for k, v := range props {
+		if !strings.HasPrefix(k, "format.") {
+			base[k] = v
+		}
+	}
+	def, err := baseFormatter(base)
+	if nil != err {
+		return nil, err
+	}
+	f := &levelFormatter{def: def, byLevel: make(map[logrus.Level]logrus.Formatter, len(byLevel))}
+	for level, format := range byLevel {
+		base["format"] = format
+		if f.byLevel[level], err = baseFormatter(base); nil != err {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// levelFormatter renders each level with its own formatter
+type levelFormatter struct {
+	def     logrus.Formatter
+	byLevel map[logrus.Level]logrus.Formatter
+}
+
+func (f *levelFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	if lf, ok := f.byLevel[e.Level]; ok {
+		return lf.Format(e)
+	}
+	return f.def.Format(e)
+}
+
+func baseFormatter(props map[string]string) (logrus.Formatter, error) {
+	msgKey := props["messagekey"]
+	switch props["format"] {
+	case "json":
+		f := &logrus.JSONFormatter{}
+		if msgKey != "" {
+			f.FieldMap = logrus.FieldMap{logrus.FieldKeyMsg: msgKey}
+		}
+		return f, nil
+	case "proto":
+		return &protoFormatter{}, nil
+	case "clf":
+		return clfFormatter{}, nil
+	case "auto":
+		return newAutoFormatter(), nil
+	}
+
+	order, ok := props["fieldorder"]
+	if ok || msgKey != "" {
+		return &textFormatter{
+			TimestampFormat: txtFormatter.TimestampFormat,
+			FieldOrder:      splitList(order),
+			MessageKey:      msgKey,
+		}, nil
+	}
+
+	colors, ok := props["colors"]
+	force, _ := strconv.ParseBool(props["forcecolors"])
+	if !ok && !force {
+		return txtFormatter, nil
+	}
+	f := &prefixed.TextFormatter{
+		FullTimestamp:   txtFormatter.FullTimestamp,
+		TimestampFormat: txtFormatter.TimestampFormat,
+		ForceFormatting: txtFormatter.ForceFormatting,
+		ForceColors:     force,
+	}
+	if ok {
+		scheme, err := parseColors(colors)
+		if nil != err {
+			return nil, err
+		}
+		f.SetColorScheme(scheme)
+	}
+	return f, nil
+}
+
+// ansiColors are the color names accepted by the colors property, each
+// optionally followed by +b (bold) or +h (high intensity)
+var ansiColors = map[string]bool{
+	"black": true, "red": true, "green": true, "yellow": true,
+	"blue": true, "magenta": true, "cyan": true, "white": true,
+}
+
+// parseColors reads a colors property such as "error=red,warn=yellow".
+// Levels left out keep the prefixed formatter's default color.
+func parseColors(v string) (*prefixed.ColorScheme, error) {
+	scheme := &prefixed.ColorScheme{}
+	for _, item := range splitList(v) {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("colors: %q is not level=color", item)
+		}
+		key, color := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		name := color
+		if i := strings.IndexByte(color, '+'); i >= 0 {
+			if mod := color[i+1:]; mod != "b" && mod != "h" {
+				return nil, fmt.Errorf("colors: unknown modifier in %q", color)
+			}
+			name = color[:i]
+		}
+		if !ansiColors[name] {
+			return nil, fmt.Errorf("colors: unknown color %q", color)
+		}
+		switch key {
+		case "debug":
+			scheme.DebugLevelStyle = color
+		case "info":
+			scheme.InfoLevelStyle = color
+		case "warn", "warning":
+			scheme.WarnLevelStyle = color
+		case "error":
+			scheme.ErrorLevelStyle = color
+		case "fatal":
+			scheme.FatalLevelStyle = color
+		case "panic":
+			scheme.PanicLevelStyle = color
+		case "prefix":
+			scheme.PrefixStyle = color
+		case "timestamp":
+			scheme.TimestampStyle = color
+		default:
+			return nil, fmt.Errorf("colors: unknown level %q", key)
+		}
+	}
+	return scheme, nil
+}
+
+// splitList splits a comma separated property value, dropping blanks
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
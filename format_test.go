@@ -0,0 +1,129 @@
+package logx
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFieldOrder(t *testing.T) {
+	f, _ := filterFormatter(map[string]string{"fieldorder": "request_id, user"})
+	e := &logrus.Entry{
+		Time:    time.Date(2023, 1, 1, 10, 0, 0, 0, time.Local),
+		Level:   logrus.InfoLevel,
+		Message: "hello",
+		Data:    logrus.Fields{"zeta": 1, "user": "bob", "alpha": 2, "request_id": "r1"},
+	}
+
+	for i := 0; i < 10; i++ {
+		out, err := f.Format(e)
+		if nil != err {
+			t.Fatal(err)
+		}
+		want := "[2023-01-01.10:00:00]  INFO hello request_id=r1 user=bob alpha=2 zeta=1\n"
+		if string(out) != want {
+			t.Fatalf("got %q, want %q", out, want)
+		}
+	}
+
+	e.Data = logrus.Fields{"prefix": "db", "alpha": 1}
+	out, _ := f.Format(e)
+	if !strings.Contains(string(out), " INFO db: hello alpha=1") {
+		t.Errorf("prefix not rendered: %q", out)
+	}
+}
+
+func TestMessageKey(t *testing.T) {
+	e := &logrus.Entry{
+		Time:    time.Date(2023, 1, 1, 10, 0, 0, 0, time.Local),
+		Level:   logrus.InfoLevel,
+		Message: "hello world",
+		Data:    logrus.Fields{"user": "bob"},
+	}
+
+	f, _ := filterFormatter(map[string]string{"format": "json", "messagekey": "log"})
+	out, err := f.Format(e)
+	if nil != err {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(out, &decoded); nil != err {
+		t.Fatal(err)
+	}
+	if decoded["log"] != "hello world" {
+		t.Errorf("message not under log: %s", out)
+	}
+	if _, ok := decoded["msg"]; ok {
+		t.Errorf("default msg key still present: %s", out)
+	}
+
+	f, _ = filterFormatter(map[string]string{"messagekey": "log"})
+	out, _ = f.Format(e)
+	if !strings.Contains(string(out), ` INFO log="hello world" user=bob`) {
+		t.Errorf("text output does not use the message key: %q", out)
+	}
+}
+
+func TestColors(t *testing.T) {
+	f, err := filterFormatter(map[string]string{"colors": "warn=cyan, error=magenta+b", "forcecolors": "true"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l := logrus.New()
+	l.SetOutput(&syncBuffer{})
+	e := &logrus.Entry{Logger: l, Time: time.Now(), Level: logrus.WarnLevel, Message: "careful", Data: logrus.Fields{}}
+	out, _ := f.Format(e)
+	if !strings.Contains(string(out), "\x1b[0;36m WARN") {
+		t.Errorf("warn is not cyan: %q", out)
+	}
+	e.Level = logrus.ErrorLevel
+	out, _ = f.Format(e)
+	if !strings.Contains(string(out), "\x1b[0;1;35mERROR") {
+		t.Errorf("error is not bold magenta: %q", out)
+	}
+	e.Level = logrus.InfoLevel
+	out, _ = f.Format(e)
+	if !strings.Contains(string(out), "\x1b[0;32m INFO") {
+		t.Errorf("info lost its default green: %q", out)
+	}
+
+	for _, bad := range []string{"warn=purple", "loud=red", "warn", "warn=red+x"} {
+		if _, err := filterFormatter(map[string]string{"colors": bad}); nil == err {
+			t.Errorf("colors=%q accepted", bad)
+		}
+	}
+}
+
+func TestPerLevelFormat(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "ops", Type: "console", Level: "info",
+		Properties: map[string]string{"format.error": "json"}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	out := &syncBuffer{}
+	l := GetLogger("ops")
+	l.SetOutput(out)
+	l.WithField("user", "bob").Info("human readable")
+	l.WithField("user", "bob").Error("for the alert parser")
+
+	lines := out.Lines()
+	if !strings.Contains(lines[0], " INFO human readable user=bob") {
+		t.Errorf("info line is not text: %q", lines[0])
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &decoded); nil != err {
+		t.Fatalf("error line is not json: %q", lines[1])
+	}
+	if decoded["msg"] != "for the alert parser" || decoded["user"] != "bob" {
+		t.Errorf("error line decoded to %v", decoded)
+	}
+
+	if _, err := filterFormatter(map[string]string{"format.loud": "json"}); nil == err {
+		t.Error("unknown level accepted")
+	}
+}
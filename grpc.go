@@ -0,0 +1,65 @@
+package logx
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcLevel maps an RPC status code to the level it is logged at: caller
+// errors are Warn, server side failures are Error
+func grpcLevel(code codes.Code) logrus.Level {
+	switch code {
+	case codes.OK:
+		return logrus.InfoLevel
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange:
+		return logrus.WarnLevel
+	default:
+		return logrus.ErrorLevel
+	}
+}
+
+func logRPC(ctx context.Context, tag string, method string, start time.Time, err error) {
+	code := status.Code(err)
+	entry := GetLogger(tag).WithFields(logrus.Fields{
+		"method":   method,
+		"code":     code.String(),
+		"duration": time.Since(start),
+	})
+	if p, ok := peer.FromContext(ctx); ok && nil != p.Addr {
+		entry = entry.WithField("peer", p.Addr.String())
+	}
+	if nil != err {
+		entry = entry.WithError(err)
+	}
+	entry.Log(grpcLevel(code), "grpc request")
+}
+
+// UnaryServerInterceptor logs every unary RPC through the logger named tag
+// with its method, status code, duration and peer address.
+func UnaryServerInterceptor(tag string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logRPC(ctx, tag, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor; the entry is written once the stream ends.
+func StreamServerInterceptor(tag string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logRPC(ss.Context(), tag, info.FullMethod, start, err)
+		return err
+	}
+}
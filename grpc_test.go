@@ -0,0 +1,82 @@
+package logx
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func waitEntry(t *testing.T, hook *test.Hook, n int) *logrus.Entry {
+	deadline := time.Now().Add(time.Second)
+	for len(hook.AllEntries()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d entries, want %d", len(hook.AllEntries()), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return hook.AllEntries()[n-1]
+}
+
+func TestGRPCInterceptors(t *testing.T) {
+	t.Cleanup(Snapshot())
+	if err := InitLoggerFromFilters(t.TempDir(), []FilterConfig{{Tag: "grpc-test", Type: "file", Level: "info"}}); nil != err {
+		t.Fatal(err)
+	}
+	hook := test.NewLocal(GetLogger("grpc-test"))
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor("grpc-test")),
+		grpc.StreamInterceptor(StreamServerInterceptor("grpc-test")),
+	)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); nil != err {
+		t.Fatal(err)
+	}
+	e := waitEntry(t, hook, 1)
+	if e.Level != logrus.InfoLevel || e.Data["code"] != "OK" || e.Data["method"] != "/grpc.health.v1.Health/Check" {
+		t.Errorf("unexpected entry %v %v", e.Level, e.Data)
+	}
+	if _, ok := e.Data["peer"]; !ok {
+		t.Errorf("missing peer field: %v", e.Data)
+	}
+
+	client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "missing"})
+	e = waitEntry(t, hook, 2)
+	if e.Level != logrus.WarnLevel || e.Data["code"] != "NotFound" || nil == e.Data[logrus.ErrorKey] {
+		t.Errorf("unexpected entry %v %v", e.Level, e.Data)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	stream.Recv()
+	cancel()
+	e = waitEntry(t, hook, 3)
+	if e.Data["method"] != "/grpc.health.v1.Health/Watch" || e.Data["code"] != "Canceled" {
+		t.Errorf("unexpected stream entry %v %v", e.Level, e.Data)
+	}
+}
@@ -0,0 +1,81 @@
+package logx
+
+import (
+	"errors"
+	"io"
+	"net"
+	"path"
+	"time"
+)
+
+// healthTimeout bounds HealthCheck as a whole
+var healthTimeout = 2 * time.Second
+
+// HealthCheck probes the output of every configured tag and reports nil
+// for the healthy ones: files must be creatable in their directory and
+// network collectors must accept a connection. Checks run concurrently and
+// tags still checking after 2s report a timeout, so it is cheap enough for
+// a readiness probe.
+func HealthCheck() map[string]error {
+	lock.RLock()
+	checked := make(map[string]io.Writer, len(outputs))
+	for tag, w := range outputs {
+		checked[tag] = w
+	}
+	lock.RUnlock()
+
+	type result struct {
+		tag string
+		err error
+	}
+	done := make(chan result, len(checked))
+	for tag, w := range checked {
+		go func(tag string, w io.Writer) {
+			done <- result{tag, outputHealth(w)}
+		}(tag, w)
+	}
+
+	timeout := time.NewTimer(healthTimeout)
+	defer timeout.Stop()
+	report := make(map[string]error, len(checked))
+	for len(checked) > 0 {
+		select {
+		case r := <-done:
+			report[r.tag] = r.err
+			delete(checked, r.tag)
+		case <-timeout.C:
+			for tag := range checked {
+				report[tag] = errors.New("health check timed out")
+			}
+			return report
+		}
+	}
+	return report
+}
+
+// outputHealth checks the destination behind w and its wrappers
+func outputHealth(w io.Writer) error {
+	switch t := w.(type) {
+	case *fileWriter:
+		return preflight(t.name)
+	case *splitWriter:
+		return preflight(path.Join(t.dir, t.tag+".log"))
+	case *templateWriter:
+		return preflight(t.name)
+	case *netWriter:
+		conn, err := net.DialTimeout(t.network, t.address, t.timeout)
+		if nil != err {
+			return err
+		}
+		return conn.Close()
+	case wrapper:
+		return outputHealth(t.unwrap())
+	case multiWrapper:
+		for _, member := range t.unwrapAll() {
+			if err := outputHealth(member); nil != err {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package logx
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+func TestHealthCheck(t *testing.T) {
+	defer Snapshot()()
+	lock.Lock()
+	outputs = make(map[string]io.Writer)
+	lock.Unlock()
+
+	// a port nobody listens on any more
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	address := lis.Addr().String()
+	lis.Close()
+
+	dir := t.TempDir()
+	err = InitLoggerFromFilters(dir, []FilterConfig{
+		{Tag: "disk", Type: "file", Level: "info"},
+		{Tag: "collector", Type: "network", Level: "info", Properties: map[string]string{"address": address}},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	report := HealthCheck()
+	if err, ok := report["disk"]; !ok || nil != err {
+		t.Errorf("file output reported %v", err)
+	}
+	if err, ok := report["collector"]; !ok || nil == err {
+		t.Error("unreachable collector reported healthy")
+	}
+}
@@ -0,0 +1,49 @@
+package logx
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// started is when the process started, as far as uptime is concerned
+var started = time.Now()
+
+// EnableHeartbeat logs an Info entry on the logger named tag every interval,
+// with heartbeat=true, the uptime of the process and the emitted, dropped
+// and bytes counters of the tag (see Stats), so that pipelines can tell an
+// idle process from a hung one. The returned cancel stops the heartbeats;
+// none is logged once it returns.
+func EnableHeartbeat(tag string, interval time.Duration) (cancel func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+			s := Stats()[tag]
+			GetLogger(tag).WithFields(logrus.Fields{
+				"heartbeat": true,
+				"uptime":    time.Since(started).Round(time.Second).String(),
+				"emitted":   s.Emitted,
+				"dropped":   s.Dropped,
+				"bytes":     s.Bytes,
+			}).Info("heartbeat")
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			<-stopped
+		})
+	}
+}
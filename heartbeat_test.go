@@ -0,0 +1,39 @@
+package logx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestEnableHeartbeat(t *testing.T) {
+	defer Snapshot()()
+	logger, hook := test.NewNullLogger()
+	lock.Lock()
+	loggers["heartbeat-test"] = logger
+	lock.Unlock()
+
+	cancel := EnableHeartbeat("heartbeat-test", 20*time.Millisecond)
+	time.Sleep(110 * time.Millisecond)
+	cancel()
+	n := len(hook.AllEntries())
+	if n < 3 || n > 6 {
+		t.Errorf("%d heartbeats in 110ms at 20ms", n)
+	}
+	e := hook.LastEntry()
+	if nil == e || e.Data["heartbeat"] != true || e.Message != "heartbeat" {
+		t.Fatalf("heartbeat entry %v", e)
+	}
+	for _, k := range []string{"uptime", "emitted", "dropped", "bytes"} {
+		if _, ok := e.Data[k]; !ok {
+			t.Errorf("heartbeat lacks %s", k)
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if after := len(hook.AllEntries()); after != n {
+		t.Errorf("%d heartbeats after cancel", after-n)
+	}
+	cancel()
+}
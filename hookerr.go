@@ -0,0 +1,63 @@
+package logx
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// hookErrorHandler holds the func(tag, hook string, err error) set by
+// SetHookErrorHandler
+var hookErrorHandler atomic.Value
+
+// SetHookErrorHandler makes fn handle the errors of the hooks of managed
+// loggers, such as an unreachable network output, in place of logrus,
+// which prints them to stderr and skips the hooks after the failing one.
+// fn gets the logger tag, the hook type and the error, and the other hooks
+// still fire. A nil fn brings the logrus behavior back.
+func SetHookErrorHandler(fn func(tag string, hook string, err error)) {
+	hookErrorHandler.Store(fn)
+}
+
+// guardedHook reports the errors of inner to the hook error handler
+type guardedHook struct {
+	tag   string
+	inner logrus.Hook
+}
+
+func (h *guardedHook) Levels() []logrus.Level {
+	return h.inner.Levels()
+}
+
+func (h *guardedHook) Fire(e *logrus.Entry) error {
+	err := h.inner.Fire(e)
+	if nil == err {
+		return nil
+	}
+	fn, _ := hookErrorHandler.Load().(func(tag string, hook string, err error))
+	if nil == fn {
+		return err
+	}
+	fn(h.tag, fmt.Sprintf("%T", h.inner), err)
+	return nil
+}
+
+// guard wraps h for the logger named tag
+func guard(tag string, h logrus.Hook) logrus.Hook {
+	if _, ok := h.(*guardedHook); ok {
+		return h
+	}
+	return &guardedHook{tag: tag, inner: h}
+}
+
+// guardHooks wraps every hook of l, keeping their order
+func guardHooks(tag string, l *logrus.Logger) {
+	hooks := make(logrus.LevelHooks, len(l.Hooks))
+	for level, list := range l.Hooks {
+		for _, h := range list {
+			hooks[level] = append(hooks[level], guard(tag, h))
+		}
+	}
+	l.ReplaceHooks(hooks)
+}
@@ -0,0 +1,61 @@
+package logx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// failingHook fails every Fire
+type failingHook struct{}
+
+func (failingHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (failingHook) Fire(*logrus.Entry) error {
+	return errors.New("collector unreachable")
+}
+
+func TestSetHookErrorHandler(t *testing.T) {
+	defer Snapshot()()
+	type failure struct{ tag, hook, err string }
+	var got []failure
+	SetHookErrorHandler(func(tag string, hook string, err error) {
+		got = append(got, failure{tag, hook, err.Error()})
+	})
+	defer SetHookErrorHandler(nil)
+
+	out := &syncBuffer{}
+	l := newTestLogger("shipping", out)
+	l.AddHook(failingHook{})
+	after := &countingHook{}
+	l.AddHook(after)
+	if err := Register("shipping", l); nil != err {
+		t.Fatal(err)
+	}
+	l.Info("shipped")
+
+	if len(got) != 1 || got[0] != (failure{"shipping", "logx.failingHook", "collector unreachable"}) {
+		t.Errorf("handler got %v", got)
+	}
+	if after.n != 1 {
+		t.Error("hooks after the failing one did not fire")
+	}
+	if !strings.Contains(out.Lines()[0], "shipped") {
+		t.Error("entry not written")
+	}
+}
+
+type countingHook struct{ n int }
+
+func (h *countingHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *countingHook) Fire(*logrus.Entry) error {
+	h.n++
+	return nil
+}
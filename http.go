@@ -0,0 +1,225 @@
+package logx
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// statusRecorder captures the status code written by the wrapped handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// httpLevel maps a response status to the level it is logged at
+func httpLevel(status int) logrus.Level {
+	switch {
+	case status >= 500:
+		return logrus.ErrorLevel
+	case status >= 400:
+		return logrus.WarnLevel
+	default:
+		return logrus.InfoLevel
+	}
+}
+
+// HTTPMiddleware logs every request through the logger named tag with its
+// method, path, status, duration and remote address. 5xx responses are
+// logged at Error, 4xx at Warn and everything else at Info.
+func HTTPMiddleware(tag string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			GetLogger(tag).WithFields(logrus.Fields{
+				"method":      r.Method,
+				"path":        r.URL.Path,
+				"status":      rec.status,
+				"duration":    time.Since(start),
+				"remote_addr": r.RemoteAddr,
+			}).Log(httpLevel(rec.status), "http request")
+		})
+	}
+}
+
+// redactedValue replaces the values HTTPTransport must not log
+const redactedValue = "[REDACTED]"
+
+// httpRedaction is what HTTPTransport hides besides url passwords; see
+// SetHTTPRedaction
+type httpRedaction struct {
+	headers map[string]bool // canonical header names
+	params  map[string]bool
+}
+
+var redaction atomic.Value // httpRedaction
+
+func init() {
+	SetHTTPRedaction(nil, nil)
+}
+
+// SetHTTPRedaction sets the headers, matched whatever their case, and the
+// url query parameters whose values HTTPTransport logs as "[REDACTED]".
+// The Authorization, Proxy-Authorization, Cookie and Set-Cookie headers
+// are always redacted, like url passwords. It applies to the transports
+// created before the call too.
+func SetHTTPRedaction(headers []string, queryParams []string) {
+	r := httpRedaction{headers: make(map[string]bool), params: make(map[string]bool)}
+	for _, h := range append([]string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}, headers...) {
+		r.headers[http.CanonicalHeaderKey(strings.TrimSpace(h))] = true
+	}
+	for _, p := range queryParams {
+		r.params[strings.TrimSpace(p)] = true
+	}
+	redaction.Store(r)
+}
+
+// url renders u without its password and the values of the redacted query
+// parameters
+func (r httpRedaction) url(u *url.URL) string {
+	q := u.Query()
+	hidden := false
+	for name := range q {
+		if r.params[name] {
+			q[name] = []string{redactedValue}
+			hidden = true
+		}
+	}
+	if !hidden {
+		return u.Redacted()
+	}
+	c := *u
+	c.RawQuery = q.Encode()
+	return c.Redacted()
+}
+
+// header renders h as a field, one value per name, with the redacted ones
+// replaced
+func (r httpRedaction) header(h http.Header) map[string]string {
+	fields := make(map[string]string, len(h))
+	for name, values := range h {
+		if r.headers[http.CanonicalHeaderKey(name)] {
+			fields[name] = redactedValue
+		} else {
+			fields[name] = strings.Join(values, ", ")
+		}
+	}
+	return fields
+}
+
+// HTTPTransport wraps next, http.DefaultTransport when nil, to log every
+// outgoing request through the logger named tag with its method, url,
+// status and duration, at the levels of HTTPMiddleware and at Error when no
+// response came back. With maxBody above zero the request and response
+// headers are logged as request_headers and response_headers, and the first
+// maxBody bytes of the bodies as request_body and response_body, marked
+// when truncated. Url passwords and the headers and query parameters of
+// SetHTTPRedaction are redacted; the fields go through the transforms like
+// any other, so an AddTransform can scrub bodies.
+func HTTPTransport(tag string, next http.RoundTripper, maxBody int) http.RoundTripper {
+	if nil == next {
+		next = http.DefaultTransport
+	}
+	return &loggingTransport{tag: tag, next: next, maxBody: maxBody}
+}
+
+type loggingTransport struct {
+	tag     string
+	next    http.RoundTripper
+	maxBody int
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := redaction.Load().(httpRedaction)
+	fields := logrus.Fields{"method": req.Method, "url": r.url(req.URL)}
+	if t.maxBody > 0 {
+		fields["request_headers"] = r.header(req.Header)
+	}
+	if t.maxBody > 0 && nil != req.Body && req.Body != http.NoBody {
+		if nil != req.GetBody {
+			if body, err := req.GetBody(); nil == err {
+				prefix, _ := ioutil.ReadAll(io.LimitReader(body, int64(t.maxBody)+1))
+				body.Close()
+				fields["request_body"] = capBody(prefix, t.maxBody)
+			}
+		} else {
+			prefix, body := peekBody(req.Body, t.maxBody)
+			req = req.Clone(req.Context())
+			req.Body = body
+			fields["request_body"] = capBody(prefix, t.maxBody)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	fields["duration"] = time.Since(start)
+	if nil != err {
+		GetLogger(t.tag).WithFields(fields).WithError(err).Error("http client request")
+		return resp, err
+	}
+	fields["status"] = resp.StatusCode
+	if t.maxBody > 0 {
+		fields["response_headers"] = r.header(resp.Header)
+	}
+	if t.maxBody > 0 && nil != resp.Body && resp.Body != http.NoBody {
+		var prefix []byte
+		prefix, resp.Body = peekBody(resp.Body, t.maxBody)
+		fields["response_body"] = capBody(prefix, t.maxBody)
+	}
+	GetLogger(t.tag).WithFields(fields).Log(httpLevel(resp.StatusCode), "http client request")
+	return resp, nil
+}
+
+// peekBody reads up to max+1 bytes of body and returns them with a body
+// that still yields everything
+func peekBody(body io.ReadCloser, max int) ([]byte, io.ReadCloser) {
+	prefix, _ := ioutil.ReadAll(io.LimitReader(body, int64(max)+1))
+	return prefix, struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), body), body}
+}
+
+// capBody renders the captured prefix of a body, cut to max bytes
+func capBody(prefix []byte, max int) string {
+	if len(prefix) > max {
+		return truncate(string(prefix), max)
+	}
+	return string(prefix)
+}
@@ -0,0 +1,121 @@
+package logx
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// latencyHook observes a numeric field of every entry into a histogram
+// labeled by the tag of the logger
+type latencyHook struct {
+	field    string
+	vec      *prometheus.HistogramVec
+	observer prometheus.Observer
+}
+
+func (h *latencyHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *latencyHook) withTag(tag string) logrus.Hook {
+	return &latencyHook{field: h.field, vec: h.vec, observer: h.vec.WithLabelValues(tag)}
+}
+
+func (h *latencyHook) Fire(e *logrus.Entry) error {
+	if nil == h.observer {
+		return nil
+	}
+	if v, ok := numericValue(e.Data[h.field]); ok {
+		h.observer.Observe(v)
+	}
+	return nil
+}
+
+// numericValue converts a field value to a float; durations are converted
+// to seconds
+func numericValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case time.Duration:
+		return n.Seconds(), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, nil == err
+	}
+	return 0, false
+}
+
+// latencyBuckets holds the buckets of the histogram registered for each
+// field; callers hold lock
+var latencyBuckets = map[string][]float64{}
+
+// EnableLatencyHistogram observes the value of field, for every entry of
+// the managed loggers carrying it, into the logx_field_latency histogram
+// labeled by logger tag and registered with the default Prometheus
+// registry. Values are observed as logged (durations in seconds); entries
+// without a numeric field are ignored. nil buckets use the Prometheus
+// defaults. Enabling a field again is a no-op, or an error when the
+// buckets differ from those it was registered with.
+func EnableLatencyHistogram(field string, buckets []float64) error {
+	if nil == buckets {
+		buckets = prometheus.DefBuckets
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if registered, ok := latencyBuckets[field]; ok && !sameBuckets(registered, buckets) {
+		return fmt.Errorf("latency histogram of %s is registered with buckets %v", field, registered)
+	}
+	for _, h := range hooks {
+		if lh, ok := h.(*latencyHook); ok && lh.field == field {
+			return nil
+		}
+	}
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "logx_field_latency",
+		Help:        "Latency values taken from a log entry field.",
+		ConstLabels: prometheus.Labels{"field": field},
+		Buckets:     buckets,
+	}, []string{"tag"})
+	if err := prometheus.Register(vec); nil != err {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return fmt.Errorf("latency histogram of %s: %w", field, err)
+		}
+		vec = are.ExistingCollector.(*prometheus.HistogramVec)
+	}
+	latencyBuckets[field] = buckets
+	installHook(&latencyHook{field: field, vec: vec})
+	return nil
+}
+
+func sameBuckets(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
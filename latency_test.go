@@ -0,0 +1,64 @@
+package logx
+
+import (
+	"io"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// latencySamples returns the sample count and sum of the latency histogram
+// of tag, and the count of its second bucket
+func latencySamples(t *testing.T, tag string) (count uint64, sum float64, second uint64) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != "logx_field_latency" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() == "tag" && lp.GetValue() == tag {
+					h := m.GetHistogram()
+					return h.GetSampleCount(), h.GetSampleSum(), h.GetBucket()[1].GetCumulativeCount()
+				}
+			}
+		}
+	}
+	return 0, 0, 0
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	defer Snapshot()()
+	if err := EnableLatencyHistogram("duration_ms", []float64{10, 100, 1000}); nil != err {
+		t.Fatal(err)
+	}
+	// enabling the field again does not count entries twice
+	if err := EnableLatencyHistogram("duration_ms", []float64{10, 100, 1000}); nil != err {
+		t.Fatal(err)
+	}
+	if err := EnableLatencyHistogram("duration_ms", []float64{1, 2}); nil == err {
+		t.Error("different buckets accepted")
+	}
+
+	dir := t.TempDir()
+	if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "api", Type: "console", Level: "info"}}); nil != err {
+		t.Fatal(err)
+	}
+	count, sum, second := latencySamples(t, "api")
+	l := GetLogger("api")
+	l.SetOutput(io.Discard)
+	l.WithField("duration_ms", 42).Info("handled")
+	l.WithField("duration_ms", "250.5").Info("handled")
+	l.Info("no latency here")
+
+	c, s, b := latencySamples(t, "api")
+	if c-count != 2 || s-sum != 292.5 {
+		t.Errorf("observed %d samples summing %v", c-count, s-sum)
+	}
+	if b-second != 1 {
+		t.Errorf("%d samples under 100ms, want 1", b-second)
+	}
+}
@@ -0,0 +1,72 @@
+package logx
+
+import (
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// levelGate is the level function of a tag along with the level the
+// logger had before it, restored when the function is removed
+type levelGate struct {
+	fn     func(*logrus.Entry) bool
+	static logrus.Level
+}
+
+// levelGates holds a map[string]*levelGate by tag, replaced on every change
+// so entries read it without locking
+var levelGates atomic.Value
+
+// SetLevelFunc makes fn decide, instead of the level, which entries of the
+// logger named tag are written: the logger then lets every level through
+// and drops the entries fn returns false for, e.g. to log at debug only for
+// flagged tenants. fn runs for every entry, so keep it cheap; the output
+// thresholds of a multi-output logger still apply. It also covers loggers
+// configured later under tag. A nil fn brings the level back.
+func SetLevelFunc(tag string, fn func(*logrus.Entry) bool) {
+	lock.Lock()
+	defer lock.Unlock()
+	old := currentGates()
+	gates := make(map[string]*levelGate, len(old)+1)
+	for k, v := range old {
+		gates[k] = v
+	}
+	l, ok := loggers[tag]
+	prev, gated := gates[tag]
+	if nil == fn {
+		if gated && ok {
+			l.SetLevel(prev.static)
+		}
+		delete(gates, tag)
+	} else {
+		g := &levelGate{fn: fn}
+		if gated {
+			g.static = prev.static
+		} else if ok {
+			g.static = l.GetLevel()
+			l.SetLevel(logrus.TraceLevel)
+		}
+		gates[tag] = g
+	}
+	levelGates.Store(gates)
+}
+
+func currentGates() map[string]*levelGate {
+	gates, _ := levelGates.Load().(map[string]*levelGate)
+	return gates
+}
+
+// applyLevelGate opens a freshly built logger to every level when tag has a
+// level function; callers hold lock
+func applyLevelGate(tag string, l *logrus.Logger) {
+	if g, ok := currentGates()[tag]; ok {
+		g.static = l.GetLevel()
+		l.SetLevel(logrus.TraceLevel)
+	}
+}
+
+// gated reports whether the level function of tag drops e
+func gated(tag string, e *logrus.Entry) bool {
+	g, ok := currentGates()[tag]
+	return ok && !g.fn(e)
+}
@@ -0,0 +1,40 @@
+package logx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSetLevelFunc(t *testing.T) {
+	defer Snapshot()()
+	out := &syncBuffer{}
+	l := newTestLogger("flagged", out)
+	l.SetLevel(logrus.InfoLevel)
+	loggers["flagged"] = l
+	SetLevelFunc("flagged", func(e *logrus.Entry) bool {
+		if _, ok := e.Data["debug_tenant"]; ok {
+			return true
+		}
+		return e.Level <= logrus.InfoLevel
+	})
+	defer SetLevelFunc("flagged", nil)
+
+	l.Debug("hidden debug")
+	l.WithField("debug_tenant", "acme").Debug("flagged debug")
+	l.Info("plain info")
+	got := strings.Join(out.Lines(), "\n")
+	if strings.Contains(got, "hidden debug") || !strings.Contains(got, "flagged debug") || !strings.Contains(got, "plain info") {
+		t.Errorf("output = %q", got)
+	}
+
+	SetLevelFunc("flagged", nil)
+	if l.GetLevel() != logrus.InfoLevel {
+		t.Errorf("level after removal = %s", l.GetLevel())
+	}
+	l.WithField("debug_tenant", "acme").Debug("after removal")
+	if strings.Contains(strings.Join(out.Lines(), "\n"), "after removal") {
+		t.Error("debug written after the function was removed")
+	}
+}
@@ -0,0 +1,99 @@
+package logx
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// splitOutput opens the output of a splitlevels file filter: each level
+// goes to its own tag.<level>.log, e.g. app.error.log, opened on its first
+// entry. maxbackups.<level> and maxage.<level> override the maxbackups and
+// maxage of the filter for that level's files, so errors can be kept far
+// longer than routine logs.
+func splitOutput(logPath string, tag string, props map[string]string) (io.Writer, error) {
+	if err := preflight(path.Join(logPath, tag+".log")); nil != err {
+		return nil, fmt.Errorf("%s: %w", tag, err)
+	}
+	s := &splitWriter{files: make(map[logrus.Level]io.Writer), props: make(map[logrus.Level]map[string]string)}
+	for _, level := range logrus.AllLevels {
+		lp := make(map[string]string, len(props))
+		for k, v := range props {
+			lp[k] = v
+		}
+		for _, key := range []string{"maxbackups", "maxage"} {
+			if v, ok := props[key+"."+level.String()]; ok {
+				lp[key] = v
+			}
+		}
+		// bad retention values fail now rather than on the first entry
+		if _, ok := lp["maxage"]; ok {
+			if _, err := parseAge(lp["maxage"]); nil != err {
+				return nil, fmt.Errorf("%s: %w", tag, err)
+			}
+		}
+		s.props[level] = lp
+	}
+	s.dir, s.tag = logPath, tag
+	return s, nil
+}
+
+// splitWriter is the levelWriter of a splitlevels filter
+type splitWriter struct {
+	mu    sync.Mutex
+	dir   string
+	tag   string
+	props map[logrus.Level]map[string]string
+	files map[logrus.Level]io.Writer
+}
+
+func (s *splitWriter) Write(p []byte) (int, error) {
+	return s.WriteLevel(logrus.InfoLevel, p)
+}
+
+func (s *splitWriter) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[level]
+	if !ok {
+		var err error
+		f, err = fileOutput(nil, path.Join(s.dir, s.tag+"."+level.String()+".log"), s.tag, s.props[level])
+		if nil != err {
+			return 0, err
+		}
+		s.files[level] = f
+	}
+	n, err := f.Write(p)
+	if nil == err && level <= logrus.FatalLevel {
+		err = flushOutput(f)
+	}
+	return n, err
+}
+
+func (s *splitWriter) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var first error
+	for _, f := range s.files {
+		if err := flushOutput(f); nil != err && nil == first {
+			first = err
+		}
+	}
+	return first
+}
+
+func (s *splitWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var first error
+	for level, f := range s.files {
+		if err := closeWriter(f); nil != err && nil == first {
+			first = err
+		}
+		delete(s.files, level)
+	}
+	return first
+}
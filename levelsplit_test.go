@@ -0,0 +1,83 @@
+package logx
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSplitLevelsRetention(t *testing.T) {
+	defer Snapshot()()
+	fc := &fakeClock{now: time.Now()}
+	SetClock(fc)
+	defer SetClock(nil)
+
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "split", Type: "file", Level: "info",
+		Properties: map[string]string{"splitlevels": "true", "maxage.info": "1d", "maxage.error": "90d"}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l := GetLogger("split")
+	l.Info("routine")
+	l.Error("failure")
+	s := outputs["split"].(*splitWriter)
+	for _, level := range []logrus.Level{logrus.InfoLevel, logrus.ErrorLevel} {
+		if err := rotatingFile(s.files[level]).Rotate(); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	// file ages are read from the files, so they are made two days old
+	// before the clock moves on to the next rotation
+	old := time.Now().Add(-48 * time.Hour)
+	files, _ := filepath.Glob(filepath.Join(dir, "split.*.log-*"))
+	for _, f := range files {
+		if err := os.Chtimes(f, old, old); nil != err {
+			t.Fatal(err)
+		}
+	}
+	fc.Advance(48 * time.Hour)
+	l.Info("routine")
+	l.Error("failure")
+
+	var infos, errs []string
+	deadline := time.Now().Add(time.Second)
+	for {
+		infos, _ = filepath.Glob(filepath.Join(dir, "split.info.log-*"))
+		errs, _ = filepath.Glob(filepath.Join(dir, "split.error.log-*"))
+		if len(infos) == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(infos) != 1 {
+		t.Errorf("info files %v, want only the current one", infos)
+	}
+	if len(errs) != 3 {
+		t.Errorf("error files %v, want all three kept", errs)
+	}
+}
+
+func TestSplitLevelsFileProperties(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "split", Type: "file", Level: "info",
+		Properties: map[string]string{"splitlevels": "true", "recordsep": `\0`}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l := GetLogger("split")
+	l.Error("first")
+	l.Error("second")
+
+	contents, _ := ioutil.ReadFile(filepath.Join(dir, "split.error.log"))
+	if records := strings.Split(string(contents), "\x00"); len(records) != 3 || !strings.Contains(records[1], "second") {
+		t.Errorf("recordsep ignored, records %q", records)
+	}
+}
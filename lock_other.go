@@ -0,0 +1,18 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package logx
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile is not available on this platform
+func lockFile(f *os.File) error {
+	return errors.New("exclusive log paths are not supported on this platform")
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
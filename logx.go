@@ -1,6 +1,13 @@
+// Package logx builds rotating logrus loggers from a log.xml style
+// configuration.
+//
+// Importing the package sets the formatter of the logrus standard logger
+// to the package text formatter and its level to Info, so anything logged
+// through logrus before InitLogger runs already looks like managed output.
 package logx
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
@@ -9,17 +16,62 @@ import (
 	prefixed "github.com/x-cray/logrus-prefixed-formatter"
 	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var loggers = make(map[string]*logrus.Logger)
+
+// outputs holds the writers InitLogger opened, by tag, so they can be
+// flushed and closed on shutdown
+var outputs = make(map[string]io.Writer)
+
+// destinations describes where each configured tag writes, for
+// LogConfigSummary
+var destinations = make(map[string]string)
+var lock sync.RWMutex
 var txtFormatter *prefixed.TextFormatter
 
+// clock drives every rotating writer; see SetClock
+var clock atomic.Value
+
+// rotateClock is handed to rotatelogs so that SetClock also affects writers
+// that have already been created
+var rotateClock = clockFunc(func() time.Time {
+	return clock.Load().(clockHolder).Clock.Now()
+})
+
+// clockHolder keeps the concrete type stored in clock stable
+type clockHolder struct {
+	rotatelogs.Clock
+}
+
+type clockFunc func() time.Time
+
+func (f clockFunc) Now() time.Time {
+	return f()
+}
+
+// SetClock replaces the clock used by all rotating writers to decide when to
+// rotate and how to name files. It exists so tests can fast-forward time;
+// passing nil restores the system clock. c is called from the goroutines
+// of the writers and must be safe for concurrent use.
+func SetClock(c rotatelogs.Clock) {
+	if nil == c {
+		c = rotatelogs.Local
+	}
+	clock.Store(clockHolder{c})
+}
+
 func init() {
+	SetClock(nil)
 	txtFormatter = &prefixed.TextFormatter{
 		FullTimestamp:   true,
 		TimestampFormat: "2006-01-02.15:04:05",
@@ -27,6 +79,8 @@ func init() {
 		ForceColors:     false,
 	}
 	std := logrus.StandardLogger()
+	std.SetFormatter(txtFormatter)
+	std.SetLevel(logrus.InfoLevel)
 	logrus.AddHook(lfshook.NewHook(lfshook.WriterMap{
 		logrus.ErrorLevel: std.Out,
 		logrus.PanicLevel: std.Out,
@@ -45,104 +99,570 @@ type xmlProperty struct {
 	Value string `xml:",chardata"`
 }
 
+type xmlOutput struct {
+	Level    string        `xml:"level"`
+	Type     string        `xml:"type"`
+	Property []xmlProperty `xml:"property"`
+}
+
 type xmlFilter struct {
 	Enabled  string        `xml:"enabled,attr"`
 	Tag      string        `xml:"tag"`
 	Level    string        `xml:"level"`
 	Type     string        `xml:"type"`
 	Property []xmlProperty `xml:"property"`
+	Output   []xmlOutput   `xml:"output"`
+
+	// MaxSize and MaxBackups may be given as elements instead of
+	// properties, and win over them
+	MaxSize    string `xml:"maxsize"`
+	MaxBackups string `xml:"maxbackups"`
 }
 
 type xmlLoggerConfig struct {
 	Filter []xmlFilter `xml:"filter"`
 }
 
+// FilterConfig describes one logger: its tag, level, output type
+// ("console", "file", "network", "syslog" or one of RegisterOutputType)
+// and the output properties (maxsize, maxbackups...). When Outputs is set
+// the logger writes to each of them instead, each with its own level
+// threshold; the forward property is a shorthand for a second, remote
+// output.
+//
+// A logger only writes to its own outputs. Only the logrus standard logger
+// is routed to the stdout and stderr loggers, unless the additive property
+// is true: the logger's entries then also land there, Error and above in
+// stderr and the rest in stdout.
+type FilterConfig struct {
+	Tag        string            `json:"tag"`
+	Type       string            `json:"type"`
+	Level      string            `json:"level"`
+	Properties map[string]string `json:"properties,omitempty"`
+	Outputs    []OutputConfig    `json:"outputs,omitempty"`
+}
+
+// OutputConfig is one destination of a multi-output filter. An empty Level
+// inherits the filter level; Properties are merged over the filter ones.
+type OutputConfig struct {
+	Type       string            `json:"type"`
+	Level      string            `json:"level,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+func xmlToProperties(props []xmlProperty) map[string]string {
+	m := make(map[string]string, len(props))
+	for _, prop := range props {
+		m[prop.Name] = strings.Trim(prop.Value, " \r\n")
+	}
+	return m
+}
+
+func (x xmlFilter) toFilterConfig() FilterConfig {
+	fc := FilterConfig{
+		Tag:        strings.TrimSpace(x.Tag),
+		Type:       strings.TrimSpace(x.Type),
+		Level:      strings.TrimSpace(x.Level),
+		Properties: xmlToProperties(x.Property),
+	}
+	if v := strings.TrimSpace(x.MaxSize); v != "" {
+		fc.Properties["maxsize"] = v
+	}
+	if v := strings.TrimSpace(x.MaxBackups); v != "" {
+		fc.Properties["maxbackups"] = v
+	}
+	for _, o := range x.Output {
+		fc.Outputs = append(fc.Outputs, OutputConfig{
+			Type:       strings.TrimSpace(o.Type),
+			Level:      strings.TrimSpace(o.Level),
+			Properties: xmlToProperties(o.Property),
+		})
+	}
+	return fc
+}
+
+// exitFunc replaces os.Exit on Fatal when set; see SetExitFunc
+var exitFunc func(int)
+
+// SetExitFunc replaces the function Fatal calls to terminate the process, on
+// the standard logger and on every managed logger, current and future. Tests
+// use it to turn Fatal into a panic or a no-op; passing nil restores os.Exit.
+// Overriding it in production means Fatal no longer stops the process, so
+// code after a Fatal call keeps running. Loggers configured with an onfatal
+// action other than exit keep it.
+func SetExitFunc(fn func(int)) {
+	lock.Lock()
+	defer lock.Unlock()
+	exitFunc = fn
+	if nil == fn {
+		fn = os.Exit
+	}
+	logrus.StandardLogger().ExitFunc = fn
+	for tag, l := range loggers {
+		if !ownsExit(configs[tag].Properties) {
+			l.ExitFunc = fn
+		}
+	}
+}
+
+// hooks are installed on every managed logger; see addHook
+var hooks []logrus.Hook
+
+// addHook installs h on every managed logger, current and future. On the
+// loggers already built it goes ahead of the hooks delivering entries to
+// outputs, so that what h changes in an entry is written too.
+func addHook(h logrus.Hook) {
+	lock.Lock()
+	defer lock.Unlock()
+	installHook(h)
+}
+
+// installHook is addHook for callers holding lock
+func installHook(h logrus.Hook) {
+	hooks = append(hooks, h)
+	for tag, l := range loggers {
+		addBeforeOutputs(l, hookFor(h, tag))
+	}
+}
+
+// addBeforeOutputs inserts h in the hooks of l in front of the first output
+// hook of each of its levels
+func addBeforeOutputs(l *logrus.Logger, h logrus.Hook) {
+	hooks := make(logrus.LevelHooks, len(l.Hooks))
+	for level, list := range l.Hooks {
+		hooks[level] = list
+	}
+	for _, level := range h.Levels() {
+		list := hooks[level]
+		i := 0
+		for i < len(list) && !isOutputHook(list[i]) {
+			i++
+		}
+		moved := make([]logrus.Hook, 0, len(list)+1)
+		moved = append(append(append(moved, list[:i]...), h), list[i:]...)
+		hooks[level] = moved
+	}
+	l.ReplaceHooks(hooks)
+}
+
+// isOutputHook reports whether h writes entries to an output
+func isOutputHook(h logrus.Hook) bool {
+	if g, ok := h.(*guardedHook); ok {
+		h = g.inner
+	}
+	switch h.(type) {
+	case *lfshook.LfsHook, *levelHook, *entryHook:
+		return true
+	}
+	return false
+}
+
+// taggedHook is implemented by hooks that need the tag of the logger they
+// are installed on; each logger gets its own copy from withTag
+type taggedHook interface {
+	logrus.Hook
+	withTag(tag string) logrus.Hook
+}
+
+func hookFor(h logrus.Hook, tag string) logrus.Hook {
+	if th, ok := h.(taggedHook); ok {
+		return guard(tag, th.withTag(tag))
+	}
+	return guard(tag, h)
+}
+
+// newLogger creates a managed logger rendering through formatter
+func newLogger(tag string, level logrus.Level, formatter logrus.Formatter) *logrus.Logger {
+	l := logrus.New()
+	l.SetLevel(level)
+	l.SetFormatter(newEntryFormatter(tag, formatter))
+	if nil != exitFunc {
+		l.ExitFunc = exitFunc
+	}
+	l.AddHook(statsFor(tag))
+	for _, h := range hooks {
+		l.AddHook(hookFor(h, tag))
+	}
+	return l
+}
+
 // Load XML configuration; see conf/log.xml for documentation
 func InitLogger(logPath string, filename string) error {
+	filters, err := readXMLConfig(filename)
+	if err != nil {
+		return err
+	}
+	return InitLoggerFromFilters(logPath, filters)
+}
+
+// InitLoggerWithDefault configures from the compiled-in XML configuration
+// defaultCfg, overridden by the XML file filename when it exists: a filter
+// of the file replaces the default filter of the same tag, and filters of
+// new tags are added. A missing file is not an error, the defaults apply.
+func InitLoggerWithDefault(logPath string, filename string, defaultCfg []byte) error {
+	filters, err := parseXMLConfig(defaultCfg)
+	if err != nil {
+		return fmt.Errorf("InitLogger: Error: Could not parse default XML configuration: %w", err)
+	}
+	if filename != "" {
+		if _, err := os.Stat(filename); nil == err {
+			overrides, err := readXMLConfig(filename)
+			if err != nil {
+				return err
+			}
+			filters = overrideFilters(filters, overrides)
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("InitLogger: Error: Could not open %q for reading: %w", filename, err)
+		}
+	}
+	return InitLoggerFromFilters(logPath, filters)
+}
+
+// overrideFilters replaces the filters of base by those of overrides with
+// the same tag, in place, and appends the others
+func overrideFilters(base []FilterConfig, overrides []FilterConfig) []FilterConfig {
+	index := make(map[string]int, len(base))
+	for i, fc := range base {
+		index[fc.Tag] = i
+	}
+	merged := append([]FilterConfig(nil), base...)
+	for _, fc := range overrides {
+		if i, ok := index[fc.Tag]; ok {
+			merged[i] = fc
+			continue
+		}
+		index[fc.Tag] = len(merged)
+		merged = append(merged, fc)
+	}
+	return merged
+}
+
+// readXMLConfig reads the filters of the XML configuration file filename
+func readXMLConfig(filename string) ([]FilterConfig, error) {
 
 	// Open the configuration file
 	fd, err := os.Open(filename)
 	if err != nil {
-		return fmt.Errorf("\"InitLogger: Error: Could not open %q for reading: %w", filename, err)
+		return nil, fmt.Errorf("\"InitLogger: Error: Could not open %q for reading: %w", filename, err)
 	}
+	defer fd.Close()
 
 	contents, err := ioutil.ReadAll(fd)
 	if err != nil {
-		return fmt.Errorf("InitLogger: Error: Could not read %q: %w", filename, err)
+		return nil, fmt.Errorf("InitLogger: Error: Could not read %q: %w", filename, err)
+	}
+
+	filters, err := parseXMLConfig(contents)
+	if err != nil {
+		return nil, fmt.Errorf("InitLogger: Error: Could not parse XML configuration in %q: %w", filename, err)
 	}
+	return filters, nil
+}
 
+// parseXMLConfig reads the filters of an XML configuration
+func parseXMLConfig(contents []byte) ([]FilterConfig, error) {
 	xc := new(xmlLoggerConfig)
 	if err := xml.Unmarshal(contents, xc); err != nil {
-		return fmt.Errorf("InitLogger: Error: Could not parse XML configuration in %q: %w", filename, err)
+		return nil, err
 	}
 
+	filters := make([]FilterConfig, 0, len(xc.Filter))
 	for _, xmlfilt := range xc.Filter {
-		var filt = logrus.New()
-		level, err := logrus.ParseLevel(xmlfilt.Level)
-		if nil != err {
-			panic(err)
-		}
-		filt.SetLevel(level)
-		filt.SetFormatter(txtFormatter)
-		switch xmlfilt.Type {
-		case "console":
-			filt.SetOutput(os.Stdout)
-		case "file":
-			output, err := xmlToFileLogWriter(path.Join(logPath, xmlfilt.Tag+".log"), xmlfilt.Property)
-			if nil != err {
-				return err
-			}
-			filt.SetOutput(output)
-		}
-		loggers[xmlfilt.Tag] = filt
+		filters = append(filters, xmlfilt.toFilterConfig())
 	}
+	return filters, nil
+}
 
-	stderr, ok := loggers["stderr"]
-	if !ok {
-		stderr = logrus.New()
-		stderr.SetLevel(logrus.ErrorLevel)
-		stderr.SetFormatter(txtFormatter)
-		rotate, err := rotatelogs.New(
-			path.Join(logPath, "stderr.log-%Y%m%d%H"),
-			rotatelogs.WithLinkName(path.Join(logPath, "stderr.log")),
-			rotatelogs.WithRotationTime(time.Hour),
-		)
-		if nil != err {
-			panic(err)
+// createDefaults makes InitLogger add the stdout/stderr file loggers; see
+// SetCreateDefaults
+var createDefaults = true
+
+// console is the fallback of GetLogger when defaults are disabled
+var console *logrus.Logger
+var consoleOnce sync.Once
+
+// SetCreateDefaults controls whether InitLogger creates rotated stdout and
+// stderr file loggers when the configuration does not define them (it does
+// by default). With defaults disabled GetLogger falls back to a console
+// logger instead.
+func SetCreateDefaults(enabled bool) {
+	lock.Lock()
+	defer lock.Unlock()
+	createDefaults = enabled
+}
+
+func consoleLogger() *logrus.Logger {
+	consoleOnce.Do(func() {
+		console = newLogger("console", logrus.InfoLevel, txtFormatter)
+		console.SetOutput(os.Stdout)
+	})
+	return console
+}
+
+// InitLoggerFromFilters builds the loggers described by filters, for callers
+// that already hold their configuration in memory. InitLogger reduces its
+// XML file to the same call. On error no logger is registered and the
+// outputs opened meanwhile are closed.
+func InitLoggerFromFilters(logPath string, filters []FilterConfig) error {
+	// replayed once lock is released, as replaying logs through GetLogger
+	var replay *earlyBuffer
+	defer func() {
+		if nil != replay {
+			replay.replay()
+		}
+	}()
+	lock.Lock()
+	defer lock.Unlock()
+	if exclusive {
+		if err := lockPath(logPath); nil != err {
+			return err
 		}
-		stderr.SetOutput(rotate)
+	}
+	built, writers, err := buildLoggers(logPath, filters)
+	if nil != err {
+		return err
+	}
+	if err := checkAudit(built); nil != err {
+		closeWriters(writers)
+		return err
+	}
+	for _, fc := range filters {
+		destinations[fc.Tag] = describeFilter(logPath, fc)
+		configs[fc.Tag] = normalizeFilter(fc)
+	}
+	for tag, l := range built {
+		applyLevelGate(tag, l)
+		loggers[tag] = l
+	}
+	for tag, w := range writers {
+		outputs[tag] = w
+	}
+
+	stderr := loggers["stderr"]
+	if nil == stderr && createDefaults {
+		var file *fileWriter
+		stderr, file = defaultFileLogger(logPath, "stderr", logrus.ErrorLevel)
 		loggers["stderr"] = stderr
+		destinations["stderr"] = "file " + file.name
+		outputs["stderr"] = file
 	}
 
 	//
-	stdout, ok := loggers["stdout"]
-	if !ok {
-		stdout = logrus.New()
-		stdout.SetLevel(logrus.InfoLevel)
-		stdout.SetFormatter(txtFormatter)
-		rotate, err := rotatelogs.New(
-			path.Join(logPath, "stdout.log-%Y%m%d%H"),
-			rotatelogs.WithLinkName(path.Join(logPath, "stdout.log")),
-			rotatelogs.WithRotationTime(time.Hour),
-		)
-		if nil != err {
-			panic(err)
-		}
-		stdout.SetOutput(rotate)
+	stdout := loggers["stdout"]
+	if nil == stdout && createDefaults {
+		var file *fileWriter
+		stdout, file = defaultFileLogger(logPath, "stdout", logrus.InfoLevel)
 		loggers["stdout"] = stdout
+		destinations["stdout"] = "file " + file.name
+		outputs["stdout"] = file
 	}
 
-	logrus.AddHook(lfshook.NewHook(lfshook.WriterMap{
-		logrus.ErrorLevel: stderr.Out,
-		logrus.PanicLevel: stderr.Out,
-		logrus.FatalLevel: stderr.Out,
-	}, txtFormatter))
+	// the standard logger, and the loggers of additive filters, also write
+	// Error and above to stderr and everything else to stdout
+	for _, h := range defaultHooks("", nil, nil, stderr, stdout) {
+		logrus.AddHook(h)
+	}
+	if crash, ok := loggers["crash"]; ok {
+		h := crashHook(crash)
+		logrus.AddHook(h)
+		for tag, l := range built {
+			if tag != "crash" {
+				l.AddHook(guard(tag, h))
+			}
+		}
+	}
+	for _, fc := range filters {
+		if additive, _ := strconv.ParseBool(fc.Properties["additive"]); !additive || fc.Tag == "stdout" || fc.Tag == "stderr" {
+			continue
+		}
+		l := loggers[fc.Tag]
+		for _, h := range defaultHooks(fc.Tag, l, fc.Properties, stderr, stdout) {
+			l.AddHook(guard(fc.Tag, h))
+		}
+	}
+	replay = endEarly()
+	return nil
+}
 
-	logrus.AddHook(lfshook.NewHook(lfshook.WriterMap{
-		logrus.DebugLevel: stdout.Out,
-		logrus.InfoLevel:  stdout.Out,
-		logrus.WarnLevel:  stdout.Out,
-	}, txtFormatter))
+// defaultFileLogger creates the stdout or stderr logger InitLogger adds
+// when the configuration lacks it, writing to hourly rotated tag.log
+func defaultFileLogger(logPath string, tag string, level logrus.Level) (*logrus.Logger, *fileWriter) {
+	l := newLogger(tag, level, txtFormatter)
+	rotate, err := newRotateLogs(
+		path.Join(logPath, tag+".log-%Y%m%d%H"),
+		withLinkName(path.Join(logPath, tag+".log")),
+		rotatelogs.WithRotationTime(time.Hour),
+	)
+	if nil != err {
+		panic(err)
+	}
+	file := &fileWriter{RotateLogs: rotate, name: path.Join(logPath, tag+".log")}
+	l.SetOutput(file)
+	return l, file
+}
+
+// defaultHooks route Error and above to stderr and the other levels to
+// stdout, skipping a nil logger. The copies of the entries of from, the
+// logger of tag with the filter properties props, are rendered as text by
+// an entryFormatter of their own, sharing its seq counter. With no tag,
+// for the standard logger, they are rendered under the stderr or stdout
+// tag.
+func defaultHooks(tag string, from *logrus.Logger, props map[string]string, stderr *logrus.Logger, stdout *logrus.Logger) []logrus.Hook {
+	var defaults []logrus.Hook
+	if nil != stderr {
+		defaults = append(defaults, defaultHook(tag, "stderr", from, props, stderr.Out,
+			logrus.ErrorLevel, logrus.PanicLevel, logrus.FatalLevel))
+	}
+
+	if nil != stdout {
+		defaults = append(defaults, defaultHook(tag, "stdout", from, props, stdout.Out,
+			logrus.DebugLevel, logrus.InfoLevel, logrus.WarnLevel))
+	}
+	return defaults
+}
+
+func defaultHook(tag string, dest string, from *logrus.Logger, props map[string]string, w io.Writer, levels ...logrus.Level) logrus.Hook {
+	if tag == "" {
+		tag = dest
+	}
+	formatter := newEntryFormatter(tag, txtFormatter)
+	if nil != from {
+		if ef, ok := from.Formatter.(*entryFormatter); ok {
+			formatter.seq = ef.seq
+		}
+	}
+	// the properties were checked when the filter was built
+	configureSampling(formatter, props)
+	configureFields(formatter, props)
+	formatter.deliver = deliverTo(w)
+	formatter.dedup.emit = formatter.emit
+	writers := lfshook.WriterMap{}
+	for _, lv := range levels {
+		writers[lv] = w
+	}
+	return lfshook.NewHook(writers, formatter)
+}
+
+// BuildLoggers builds the loggers described by filters and hands them to the
+// caller instead of registering them: GetLogger, Flush and Close do not see
+// them and the standard logger hooks are left alone. The caller owns their
+// outputs and calls the returned func to flush and close them once done
+// with the loggers.
+func BuildLoggers(logPath string, filters []FilterConfig) (map[string]*logrus.Logger, func() error, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	built, writers, err := buildLoggers(logPath, filters)
+	if nil != err {
+		return nil, nil, err
+	}
+	return built, func() error { return closeWriters(writers) }, nil
+}
+
+// buildLoggers creates one logger per filter along with the writers to
+// flush and close for them, keyed by tag. On error it closes the writers
+// opened so far and returns nothing. Callers hold lock.
+func buildLoggers(logPath string, filters []FilterConfig) (map[string]*logrus.Logger, map[string]io.Writer, error) {
+	built, writers, err := openLoggers(logPath, filters)
+	if nil != err {
+		closeWriters(writers)
+		return nil, nil, err
+	}
+	return built, writers, nil
+}
+
+// closeWriters flushes and closes writers, returning the first error
+func closeWriters(writers map[string]io.Writer) error {
+	var first error
+	for tag, w := range writers {
+		if err := closeOutput(tag, w); nil != err && nil == first {
+			first = err
+		}
+	}
+	return first
+}
+
+// openLoggers does the work of buildLoggers, returning what was built so
+// far on error
+func openLoggers(logPath string, filters []FilterConfig) (map[string]*logrus.Logger, map[string]io.Writer, error) {
+	built := make(map[string]*logrus.Logger, len(filters))
+	writers := make(map[string]io.Writer, len(filters))
+	if err := checkDuplicateTags(filters); nil != err {
+		return built, writers, err
+	}
+	for _, fc := range filters {
+		level, err := logrus.ParseLevel(fc.Level)
+		if nil != err {
+			return built, writers, fmt.Errorf("%s: %w", fc.Tag, err)
+		}
+		if fc, err = expandForward(fc); nil != err {
+			return built, writers, fmt.Errorf("%s: %w", fc.Tag, err)
+		}
+		formatter, err := filterFormatter(fc.Properties)
+		if nil != err {
+			return built, writers, fmt.Errorf("%s: %w", fc.Tag, err)
+		}
+		var filt = newLogger(fc.Tag, level, formatter)
+		if err := configureSampling(filt.Formatter, fc.Properties); nil != err {
+			return built, writers, fmt.Errorf("%s: %w", fc.Tag, err)
+		}
+		configureFields(filt.Formatter, fc.Properties)
+		exit, err := fatalExit(fc.Tag, fc.Properties)
+		if nil != err {
+			return built, writers, fmt.Errorf("%s: %w", fc.Tag, err)
+		}
+		if nil != exit {
+			filt.ExitFunc = exit
+		}
+		if v, _ := strconv.ParseBool(fc.Properties["reportcaller"]); v {
+			filt.SetReportCaller(true)
+		}
+		if prefix := fc.Properties["prefix"]; prefix != "" {
+			// ahead of the output hooks so they render it too
+			filt.AddHook(&defaultFieldsHook{fields: logrus.Fields{"prefix": prefix}})
+		}
+		if len(fc.Outputs) > 0 {
+			output, err := attachOutputs(filt, logPath, fc, level)
+			if nil != err {
+				return built, writers, err
+			}
+			writers[fc.Tag] = output
+		} else {
+			output, err := buildOutput(filt, logPath, fc.Tag, fc.Type, fc.Properties)
+			if nil != err {
+				return built, writers, err
+			}
+			configureAuto(filt.Formatter, output)
+			ef := filt.Formatter.(*entryFormatter)
+			if h := outputHook(output, level, ef); nil != h {
+				filt.AddHook(h)
+				discardOutput(filt)
+				ef.deliver = deliverTo(output)
+			} else {
+				filt.SetOutput(output)
+				// formatting runs under the logger lock, as its write does
+				ef.deliver = func(e *logrus.Entry, p []byte) { e.Logger.Out.Write(p) }
+			}
+			if output != io.Writer(os.Stdout) {
+				writers[fc.Tag] = output
+			}
+		}
+		guardHooks(fc.Tag, filt)
+		built[fc.Tag] = filt
+	}
+	return built, writers, nil
+}
+
+// checkDuplicateTags rejects filters sharing a tag, which would otherwise
+// silently replace each other
+func checkDuplicateTags(filters []FilterConfig) error {
+	seen := make(map[string]FilterConfig, len(filters))
+	for _, fc := range filters {
+		if first, ok := seen[fc.Tag]; ok {
+			return fmt.Errorf("duplicate filter tag %s: level %s type %s, then level %s type %s",
+				fc.Tag, first.Level, first.Type, fc.Level, fc.Type)
+		}
+		seen[fc.Tag] = fc
+	}
 	return nil
 }
 
@@ -165,42 +685,427 @@ func strToNumSuffix(str string, mult int) int {
 	parsed, _ := strconv.Atoi(str)
 	return parsed * num
 }
-func xmlToFileLogWriter(filename string, props []xmlProperty) (io.Writer, error) {
+
+// withLinkName points name at the current file. rotatelogs would replace a
+// regular file left at name, say by a crashed run or another tool, losing
+// it, and fails on a directory: a regular file is moved aside to
+// name.stale-<time>, and when that is not possible the writer runs without
+// a link. Both cases are reported as warnings.
+func withLinkName(name string) rotatelogs.Option {
+	fi, err := os.Lstat(name)
+	if nil != err || fi.Mode()&os.ModeSymlink != 0 {
+		return rotatelogs.WithLinkName(name)
+	}
+	if fi.Mode().IsRegular() {
+		stale := name + ".stale-" + rotateClock.Now().Format("20060102150405")
+		if err := os.Rename(name, stale); nil == err {
+			logrus.Warnf("logx: moved the regular file at link %s to %s", name, stale)
+			return rotatelogs.WithLinkName(name)
+		}
+	}
+	logrus.Warnf("logx: %s is not a symlink, writing without the link", name)
+	return rotatelogs.WithLinkName("")
+}
+
+// newRotateLogs creates a rotating writer bound to the package clock
+func newRotateLogs(pattern string, options ...rotatelogs.Option) (*rotatelogs.RotateLogs, error) {
+	return rotatelogs.New(pattern, append([]rotatelogs.Option{rotatelogs.WithClock(rotateClock)}, options...)...)
+}
+
+// hasDayToken reports whether a strftime pattern changes at least daily,
+// so rotated files of different days never collide
+func hasDayToken(pattern string) bool {
+	for i := 0; i < len(pattern)-1; i++ {
+		if pattern[i] != '%' {
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'd', 'e', 'j', 'F':
+			return true
+		}
+	}
+	return false
+}
+
+func fileLogWriter(filename string, props map[string]string) (io.Writer, error) {
 	maxbackups := uint64(10)
 	maxsize := strToNumSuffix("100M", 1024)
 
 	// Parse properties
-	for _, prop := range props {
-		switch prop.Name {
-		case "maxbackups":
-			maxbackups, _ = strconv.ParseUint(strings.Trim(prop.Value, " \r\n"), 10, 32)
-		case "maxsize":
-			maxsize = strToNumSuffix(strings.Trim(prop.Value, " \r\n"), 1024)
+	if v, ok := props["maxbackups"]; ok {
+		maxbackups, _ = strconv.ParseUint(strings.Trim(v, " \r\n"), 10, 32)
+	}
+	if v, ok := props["maxsize"]; ok {
+		maxsize = strToNumSuffix(strings.Trim(v, " \r\n"), 1024)
+	}
+	var maxage time.Duration
+	if v, ok := props["maxage"]; ok {
+		var err error
+		if maxage, err = parseAge(v); nil != err {
+			return nil, err
 		}
 	}
+	// rotationtime boundaries are aligned on the wall clock of timezone
+	// (Local by default), so 24h rotates at local midnight; daily and longer
+	// rotations name their files by day
+	rotation := time.Hour
+	if v, ok := props["rotationtime"]; ok {
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if nil != err || d <= 0 {
+			return nil, fmt.Errorf("rotationtime %q is not a positive duration", v)
+		}
+		rotation = d
+	}
+	suffix := "-%Y%m%d%H"
+	if rotation%(24*time.Hour) == 0 {
+		suffix = "-%Y%m%d"
+	}
+	if v, ok := props["filepattern"]; ok {
+		suffix = strings.TrimSpace(v)
+		if !hasDayToken(suffix) {
+			return nil, fmt.Errorf("filepattern %q needs a day token (%%d, %%e, %%j or %%F)", v)
+		}
+	}
+	pattern := filename + suffix
 
-	rotate, err := rotatelogs.New(
-		filename+"-%Y%m%d%H",
-		rotatelogs.WithLinkName(filename),
-		rotatelogs.WithRotationTime(time.Hour),
-		rotatelogs.WithRotationCount(uint(maxbackups)),
+	options := []rotatelogs.Option{
+		withLinkName(filename),
+		rotatelogs.WithRotationTime(rotation),
 		rotatelogs.WithRotationSize(int64(maxsize)),
-	)
+	}
+	if v := strings.TrimSpace(props["timezone"]); v != "" {
+		loc, err := time.LoadLocation(v)
+		if nil != err {
+			return nil, fmt.Errorf("timezone %q: %w", v, err)
+		}
+		options = append(options, rotatelogs.WithClock(clockFunc(func() time.Time {
+			return rotateClock.Now().In(loc)
+		})))
+	}
+	if v, _ := strconv.ParseBool(props["forcenewfile"]); v {
+		options = append(options, rotatelogs.ForceNewFile())
+	}
+	// maxbackups counts every file kept, the active one included, whether it
+	// was rotated by time or by size; maxage removes files last written
+	// longer ago
+	var handlers rotationHandlers
+	// archiving first, so the rotated file is open before retention may
+	// remove it
+	archive, err := s3Archive(props)
+	if nil != err {
+		return nil, err
+	}
+	if nil != archive {
+		handlers = append(handlers, archive)
+	}
+	if maxbackups > 0 || maxage > 0 {
+		options = append(options, rotatelogs.WithRotationCount(math.MaxUint32))
+		handlers = append(handlers, &retention{
+			prefix: filename + suffix[:strings.IndexByte(suffix, '%')],
+			keep:   int(maxbackups),
+			maxAge: maxage,
+		})
+	}
+	if len(handlers) > 0 {
+		options = append(options, rotatelogs.WithHandler(handlers))
+	}
+
+	rotate, err := newRotateLogs(pattern, options...)
 	if nil != err {
 		return nil, fmt.Errorf("rotatelogs open fail %w", err)
-	} else {
-		return rotate, nil
 	}
+	if v, _ := strconv.ParseBool(props["truncate"]); v {
+		// truncate empties the file of the current rotation window, which
+		// forcenewfile would leave alone for a new generation; the empty
+		// write makes rotatelogs open it
+		if _, err := rotate.Write(nil); nil != err {
+			return nil, fmt.Errorf("rotatelogs open fail %w", err)
+		}
+		if err := os.Truncate(rotate.CurrentFileName(), 0); nil != err {
+			return nil, fmt.Errorf("truncate %s: %w", rotate.CurrentFileName(), err)
+		}
+	}
+	return &fileWriter{RotateLogs: rotate, name: filename}, nil
+}
+
+// parseAge reads a maxage property: a duration such as 36h, or a number of
+// days such as 90d
+func parseAge(v string) (time.Duration, error) {
+	v = strings.TrimSpace(v)
+	if days := strings.TrimSuffix(v, "d"); days != v {
+		if n, err := strconv.Atoi(days); nil == err && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(v); nil == err && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("maxage %q is neither a positive duration nor a number of days", v)
+}
+
+// fileWriter is a rotating file output that remembers its base name
+type fileWriter struct {
+	*rotatelogs.RotateLogs
+	name string
 }
 
+// prefixFallback makes GetLogger consult GetLoggerByPrefix; see
+// SetPrefixFallback
+var prefixFallback bool
+
+// SetPrefixFallback makes GetLogger fall back to the closest dotted parent
+// tag (see GetLoggerByPrefix) before falling back to stdout. It is off by
+// default.
+func SetPrefixFallback(enabled bool) {
+	lock.Lock()
+	defer lock.Unlock()
+	prefixFallback = enabled
+}
+
+// GetLoggerByPrefix resolves name against the dotted tag hierarchy: an exact
+// tag wins, otherwise the longest configured tag that is a parent of name
+// ("db" for "db.query.slow", preferring "db.query" when both exist). It
+// returns false when nothing matches.
+func GetLoggerByPrefix(name string) (*logrus.Logger, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+	return loggerByPrefix(name)
+}
+
+func loggerByPrefix(name string) (*logrus.Logger, bool) {
+	if l, ok := loggers[name]; ok {
+		return l, true
+	}
+	for i := strings.LastIndexByte(name, '.'); i > 0; i = strings.LastIndexByte(name, '.') {
+		name = name[:i]
+		if l, ok := loggers[name]; ok {
+			return l, true
+		}
+	}
+	return nil, false
+}
+
+// GetLogger returns the logger configured for name. Unknown names fall back
+// to the parent tag (with SetPrefixFallback), then to stdout, then to the
+// logrus standard logger. Named loggers are independent of the stdout and
+// stderr hooks InitLogger installs on the standard logger: their entries
+// only reach their own outputs unless the filter is additive.
 func GetLogger(name string) *logrus.Logger {
+	lock.RLock()
+	defer lock.RUnlock()
 	if l, ok := loggers[name]; ok {
 		return l
 	}
 
+	if prefixFallback {
+		if l, ok := loggerByPrefix(name); ok {
+			return l
+		}
+	}
+
+	if nil != early {
+		return early.logger(name)
+	}
+
 	if l, ok := loggers["stdout"]; ok {
 		return l
 	}
 
+	if !createDefaults {
+		return consoleLogger()
+	}
 	return logrus.StandardLogger()
 }
+
+// Register makes l, a logger created outside logx, a managed logger under
+// tag: GetLogger returns it, SetLevel and ListLoggers see it, the package
+// hooks are installed on it and its formatter is wrapped so the package
+// features apply. Close and Flush handle its output unless it is stdout or
+// stderr. It fails when tag is already taken.
+func Register(tag string, l *logrus.Logger) error {
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := loggers[tag]; ok {
+		return fmt.Errorf("register: tag %s already exists", tag)
+	}
+	if _, ok := l.Formatter.(*entryFormatter); !ok {
+		l.SetFormatter(newEntryFormatter(tag, l.Formatter))
+	}
+	l.AddHook(statsFor(tag))
+	for _, h := range hooks {
+		l.AddHook(hookFor(h, tag))
+	}
+	guardHooks(tag, l)
+	applyLevelGate(tag, l)
+	loggers[tag] = l
+	destinations[tag] = "registered logger"
+	if l.Out != os.Stdout && l.Out != os.Stderr {
+		outputs[tag] = l.Out
+	}
+	return nil
+}
+
+// SetLevel changes the level of the managed logger named tag. With a level
+// function (see SetLevelFunc) it is the level restored on its removal.
+func SetLevel(tag string, level logrus.Level) error {
+	lock.Lock()
+	defer lock.Unlock()
+	l, ok := loggers[tag]
+	if !ok {
+		return fmt.Errorf("set level: unknown tag %s", tag)
+	}
+	if g, ok := currentGates()[tag]; ok {
+		g.static = level
+		return nil
+	}
+	l.SetLevel(level)
+	return nil
+}
+
+// ListLoggers returns the tags of the managed loggers, sorted.
+func ListLoggers() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	tags := make([]string, 0, len(loggers))
+	for tag := range loggers {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Logger is the subset of *logrus.Logger most callers need, so code can
+// depend on it and inject fakes in tests. *logrus.Entry satisfies it too.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+	WithField(key string, value interface{}) *logrus.Entry
+	WithFields(fields logrus.Fields) *logrus.Entry
+	WithError(err error) *logrus.Entry
+}
+
+var _ Logger = (*logrus.Logger)(nil)
+var _ Logger = (*logrus.Entry)(nil)
+
+// Get is GetLogger returning the Logger interface.
+func Get(name string) Logger {
+	return GetLogger(name)
+}
+
+// LogAt returns an entry of the logger named tag stamped with t instead of
+// the time it is logged, for replayed or backfilled events. Formatters
+// render t, but rotating files still switch by the wall clock, so an old
+// event lands in the current file.
+func LogAt(tag string, t time.Time) *logrus.Entry {
+	return GetLogger(tag).WithTime(t)
+}
+
+// Flush pushes any buffered output of the configured writers down to
+// their destination. It returns the first error encountered.
+func Flush() error {
+	lock.RLock()
+	defer lock.RUnlock()
+	return flushOutputs()
+}
+
+func flushOutputs() error {
+	var first error
+	for tag, w := range outputs {
+		if f, ok := w.(interface{ Flush() error }); ok {
+			if err := f.Flush(); nil != err && nil == first {
+				first = fmt.Errorf("flush %s fail %w", tag, err)
+			}
+		}
+	}
+	return first
+}
+
+// defaultCloseTimeout bounds how long Close waits for outputs to drain
+const defaultCloseTimeout = 5 * time.Second
+
+// Close flushes and closes every writer opened by InitLogger, giving up on
+// the outputs still draining after 5s. Loggers must not be used afterwards.
+func Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloseTimeout)
+	defer cancel()
+	return CloseContext(ctx)
+}
+
+// CloseContext flushes and closes every writer opened by InitLogger, all
+// at once, until ctx is done. Outputs that have not drained by then are
+// left to finish in the background and named in the returned error.
+// Otherwise it returns the first flush or close error.
+func CloseContext(ctx context.Context) error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	type result struct {
+		tag string
+		err error
+	}
+	done := make(chan result, len(outputs))
+	pending := make(map[string]bool, len(outputs))
+	for tag, w := range outputs {
+		pending[tag] = true
+		go func(tag string, w io.Writer) {
+			done <- result{tag, closeOutput(tag, w)}
+		}(tag, w)
+		delete(outputs, tag)
+	}
+
+	// the files are done with once drained or abandoned
+	defer unlockPaths()
+
+	var first error
+	for len(pending) > 0 {
+		select {
+		case r := <-done:
+			delete(pending, r.tag)
+			if nil != r.err && nil == first {
+				first = r.err
+			}
+		case <-ctx.Done():
+			tags := make([]string, 0, len(pending))
+			for tag := range pending {
+				tags = append(tags, tag)
+			}
+			sort.Strings(tags)
+			return fmt.Errorf("close: outputs %s did not drain: %w", strings.Join(tags, ", "), ctx.Err())
+		}
+	}
+	return first
+}
+
+func closeOutput(tag string, w io.Writer) error {
+	var first error
+	if f, ok := w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); nil != err {
+			first = fmt.Errorf("flush %s fail %w", tag, err)
+		}
+	}
+	if c, ok := w.(io.Closer); ok {
+		if err := c.Close(); nil != err && nil == first {
+			first = fmt.Errorf("close %s fail %w", tag, err)
+		}
+	}
+	return first
+}
+
+// OutputWriter returns the writer the logger named tag writes to, e.g. its
+// rotating file, so other output can share the same destination. Bytes
+// written to it bypass formatting and hooks. It returns false for unknown
+// tags.
+func OutputWriter(tag string) (io.Writer, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+	if w, ok := outputs[tag]; ok {
+		return w, true
+	}
+	l, ok := loggers[tag]
+	if !ok {
+		return nil, false
+	}
+	return l.Out, true
+}
@@ -1,6 +1,22 @@
 package logx
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
 
 func TestLogger(t *testing.T) {
 	err := InitLogger("./logs", "log.xml")
@@ -9,3 +25,732 @@ func TestLogger(t *testing.T) {
 	}
 	GetLogger("stdout").Infof("TestLogger %s", "1234")
 }
+
+// fakeClock is read by the rotation goroutines of rotatelogs as well
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestClockRotation(t *testing.T) {
+	fc := &fakeClock{now: time.Date(2023, 1, 1, 10, 30, 0, 0, time.Local)}
+	SetClock(fc)
+	defer SetClock(nil)
+
+	dir := t.TempDir()
+	w, err := fileLogWriter(path.Join(dir, "rotate.log"), nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer w.(io.Closer).Close()
+
+	w.Write([]byte("before\n"))
+	fc.Advance(45 * time.Minute)
+	w.Write([]byte("after\n"))
+
+	for _, name := range []string{"rotate.log-2023010110", "rotate.log-2023010111"} {
+		if _, err := os.Stat(path.Join(dir, name)); nil != err {
+			t.Errorf("expected rotated file %s: %v", name, err)
+		}
+	}
+}
+
+func TestSetExitFunc(t *testing.T) {
+	code := -1
+	SetExitFunc(func(c int) { code = c })
+	defer SetExitFunc(nil)
+
+	l := newLogger("exit-test", logrus.InfoLevel, txtFormatter)
+	l.SetOutput(io.Discard)
+	l.Fatal("fatal without exit")
+	if code != 1 {
+		t.Fatalf("exit func got %d, want 1", code)
+	}
+}
+
+func TestMaxBackups(t *testing.T) {
+	fc := &fakeClock{now: time.Date(2023, 1, 1, 10, 0, 0, 0, time.Local)}
+	SetClock(fc)
+	defer SetClock(nil)
+
+	dir := t.TempDir()
+	w, err := fileLogWriter(path.Join(dir, "keep.log"), map[string]string{"maxbackups": "3", "maxsize": "1K"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer w.(io.Closer).Close()
+
+	// a dozen size rotations in one hour, then a few time rotations
+	line := make([]byte, 2048)
+	for i := 0; i < 12; i++ {
+		w.Write(line)
+	}
+	for i := 0; i < 2; i++ {
+		fc.Advance(time.Hour)
+		w.Write(line)
+	}
+
+	var files []string
+	deadline := time.Now().Add(time.Second)
+	for {
+		files, _ = filepath.Glob(path.Join(dir, "keep.log-*"))
+		if len(files) == 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	want := []string{"keep.log-2023010110.11", "keep.log-2023010111", "keep.log-2023010112"}
+	if len(files) != len(want) {
+		t.Fatalf("retained %v, want %v", files, want)
+	}
+	for i := range want {
+		if filepath.Base(files[i]) != want[i] {
+			t.Errorf("retained %v, want %v", files, want)
+		}
+	}
+}
+
+func TestOutputWriter(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "raw", Type: "file", Level: "info"}}); nil != err {
+		t.Fatal(err)
+	}
+	w, ok := OutputWriter("raw")
+	if !ok {
+		t.Fatal("configured tag not found")
+	}
+	w.Write([]byte("raw bytes\n"))
+	content, _ := ioutil.ReadFile(path.Join(dir, "raw.log"))
+	if string(content) != "raw bytes\n" {
+		t.Errorf("got %q", content)
+	}
+
+	if _, ok := OutputWriter("no-such-tag"); ok {
+		t.Error("unknown tag reported as configured")
+	}
+}
+
+func TestForceNewFile(t *testing.T) {
+	fc := &fakeClock{now: time.Date(2023, 1, 1, 10, 0, 0, 0, time.Local)}
+	SetClock(fc)
+	defer SetClock(nil)
+
+	dir := t.TempDir()
+	filename := path.Join(dir, "run.log")
+	props := map[string]string{"forcenewfile": "true"}
+	for _, run := range []string{"first run\n", "second run\n"} {
+		w, err := fileLogWriter(filename, props)
+		if nil != err {
+			t.Fatal(err)
+		}
+		w.Write([]byte(run))
+		w.(io.Closer).Close()
+		fc.Advance(time.Minute)
+	}
+
+	for name, want := range map[string]string{
+		"run.log-2023010110":   "first run\n",
+		"run.log-2023010110.1": "second run\n",
+		"run.log":              "second run\n",
+	} {
+		content, _ := ioutil.ReadFile(path.Join(dir, name))
+		if string(content) != want {
+			t.Errorf("%s holds %q, want %q", name, content, want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	fc := &fakeClock{now: time.Date(2023, 1, 1, 10, 0, 0, 0, time.Local)}
+	SetClock(fc)
+	defer SetClock(nil)
+
+	dir := t.TempDir()
+	filename := path.Join(dir, "job.log")
+	for _, c := range []struct {
+		props map[string]string
+		run   string
+		want  string
+	}{
+		{map[string]string{}, "first run\n", "first run\n"},
+		{map[string]string{}, "second run\n", "first run\nsecond run\n"},
+		{map[string]string{"truncate": "true"}, "third run\n", "third run\n"},
+	} {
+		w, err := fileLogWriter(filename, c.props)
+		if nil != err {
+			t.Fatal(err)
+		}
+		w.Write([]byte(c.run))
+		w.(io.Closer).Close()
+		fc.Advance(time.Minute)
+		if content, _ := ioutil.ReadFile(path.Join(dir, "job.log-2023010110")); string(content) != c.want {
+			t.Errorf("after %q the file holds %q, want %q", c.run, content, c.want)
+		}
+	}
+}
+
+func TestGetLoggerByPrefix(t *testing.T) {
+	db := logrus.New()
+	query := logrus.New()
+	lock.Lock()
+	loggers["db"] = db
+	loggers["db.query"] = query
+	lock.Unlock()
+
+	for name, want := range map[string]*logrus.Logger{
+		"db":            db,
+		"db.conn":       db,
+		"db.query":      query,
+		"db.query.slow": query,
+	} {
+		if l, ok := GetLoggerByPrefix(name); !ok || l != want {
+			t.Errorf("GetLoggerByPrefix(%q) picked the wrong logger", name)
+		}
+	}
+	if _, ok := GetLoggerByPrefix("dbx"); ok {
+		t.Error("dbx matched db without a dot boundary")
+	}
+
+	if GetLogger("db.conn") == db {
+		t.Error("prefix lookup used without SetPrefixFallback")
+	}
+	SetPrefixFallback(true)
+	defer SetPrefixFallback(false)
+	if GetLogger("db.conn") != db {
+		t.Error("GetLogger did not fall back to the parent tag")
+	}
+}
+
+func TestGet(t *testing.T) {
+	l := logrus.New()
+	lock.Lock()
+	loggers["iface"] = l
+	lock.Unlock()
+	if Get("iface") != Logger(l) {
+		t.Error("Get did not return the configured logger")
+	}
+}
+
+func TestStandardLoggerFormat(t *testing.T) {
+	std := logrus.StandardLogger()
+	out := &syncBuffer{}
+	old := std.Out
+	std.SetOutput(out)
+	defer std.SetOutput(old)
+
+	logrus.Info("before init")
+	line := out.Lines()[0]
+	if !regexp.MustCompile(`^\[\d{4}-\d\d-\d\d\.\d\d:\d\d:\d\d\]  INFO before init$`).MatchString(line) {
+		t.Errorf("standard logger output %q is not in the prefixed format", line)
+	}
+}
+
+func TestSetCreateDefaults(t *testing.T) {
+	defer Snapshot()()
+	SetCreateDefaults(false)
+	defer SetCreateDefaults(true)
+
+	lock.Lock()
+	delete(loggers, "stdout")
+	delete(loggers, "stderr")
+	lock.Unlock()
+
+	dir := t.TempDir()
+	if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "only", Type: "console", Level: "info"}}); nil != err {
+		t.Fatal(err)
+	}
+	GetLogger("only").Info("console only")
+
+	files, _ := filepath.Glob(path.Join(dir, "*"))
+	if len(files) != 0 {
+		t.Errorf("default loggers created files: %v", files)
+	}
+	if l := GetLogger("unknown"); l.Out != os.Stdout || l == logrus.StandardLogger() {
+		t.Error("fallback is not a console logger")
+	}
+}
+
+func TestBuildLoggers(t *testing.T) {
+	defer Snapshot()()
+	stdHooks := len(logrus.StandardLogger().Hooks[logrus.InfoLevel])
+
+	dir := t.TempDir()
+	built, closeOutputs, err := BuildLoggers(dir, []FilterConfig{{Tag: "embedded", Type: "file", Level: "info"}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l, ok := built["embedded"]
+	if !ok {
+		t.Fatal("embedded logger not built")
+	}
+	l.Info("kept to ourselves")
+	if err := closeOutputs(); nil != err {
+		t.Fatal(err)
+	}
+
+	if _, ok := OutputWriter("embedded"); ok {
+		t.Error("built logger registered globally")
+	}
+	if GetLogger("embedded") == l {
+		t.Error("GetLogger returned the built logger")
+	}
+	if n := len(logrus.StandardLogger().Hooks[logrus.InfoLevel]); n != stdHooks {
+		t.Errorf("standard logger hooks changed from %d to %d", stdHooks, n)
+	}
+	if _, err := os.Stat(path.Join(dir, "stdout.log")); nil == err {
+		t.Error("default loggers created")
+	}
+	content, _ := ioutil.ReadFile(path.Join(dir, "embedded.log"))
+	if !regexp.MustCompile(`kept to ourselves`).Match(content) {
+		t.Errorf("embedded.log holds %q", content)
+	}
+}
+
+func TestInitLoggerFailure(t *testing.T) {
+	defer Snapshot()()
+	opened := registerRecording(t, "recording")
+	err := InitLoggerFromFilters(t.TempDir(), []FilterConfig{
+		{Tag: "good", Type: "recording", Level: "info"},
+		{Tag: "bad", Type: "nosuch", Level: "info"},
+	})
+	if nil == err {
+		t.Fatal("unknown output type accepted")
+	}
+	for _, tag := range ListLoggers() {
+		if tag == "good" || tag == "bad" {
+			t.Errorf("%s registered by the failed InitLogger", tag)
+		}
+	}
+	if len(*opened) != 1 || !(*opened)[0].closed {
+		t.Error("output of the good filter left open")
+	}
+
+	if _, _, err := BuildLoggers(t.TempDir(), []FilterConfig{{Tag: "loud", Type: "console", Level: "loud"}}); nil == err || !strings.HasPrefix(err.Error(), "loud: ") {
+		t.Errorf("invalid level gave %v", err)
+	}
+}
+
+// blockingWriter never finishes closing, like a network output stuck on an
+// unreachable collector
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+func (w *blockingWriter) Close() error {
+	<-w.release
+	return nil
+}
+
+func TestCloseContext(t *testing.T) {
+	defer Snapshot()()
+	stuck := &blockingWriter{release: make(chan struct{})}
+	defer close(stuck.release)
+	lock.Lock()
+	outputs["stuck"] = stuck
+	outputs["fine"] = &syncBuffer{}
+	lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := CloseContext(ctx)
+	if nil == err || !strings.Contains(err.Error(), "stuck") || strings.Contains(err.Error(), "fine") {
+		t.Errorf("got %v, want a timeout naming only stuck", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("%v does not wrap the deadline", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("CloseContext did not give up at the deadline")
+	}
+}
+
+func TestAdditive(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{
+		{Tag: "stdout", Type: "file", Level: "info"},
+		{Tag: "own", Type: "file", Level: "info"},
+		{Tag: "shared", Type: "file", Level: "info", Properties: map[string]string{"additive": "true"}},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	GetLogger("own").Info("only in own.log")
+	GetLogger("shared").Info("in shared.log and stdout.log")
+
+	for name, want := range map[string][]string{
+		"own.log":    {"only in own.log"},
+		"shared.log": {"in shared.log and stdout.log"},
+		"stdout.log": {"in shared.log and stdout.log"},
+	} {
+		content, _ := ioutil.ReadFile(path.Join(dir, name))
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		if len(lines) != len(want) || !strings.HasSuffix(lines[0], want[0]) {
+			t.Errorf("%s holds %q, want %q", name, lines, want)
+		}
+	}
+}
+
+func TestAdditivePipeline(t *testing.T) {
+	defer Snapshot()()
+	SetMaxMessageLength(8)
+	defer SetMaxMessageLength(0)
+	SetSequenceField(true)
+	defer SetSequenceField(false)
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{
+		{Tag: "stdout", Type: "file", Level: "info"},
+		{Tag: "shared", Type: "file", Level: "info", Properties: map[string]string{"additive": "true", "excludefields": "secret"}},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	GetLogger("shared").WithField("secret", "hunter2").Info("a message longer than eight bytes")
+
+	for _, name := range []string{"shared.log", "stdout.log"} {
+		content, _ := ioutil.ReadFile(path.Join(dir, name))
+		line := strings.TrimSpace(string(content))
+		if strings.Contains(line, "longer than eight") || strings.Contains(line, "hunter2") || !strings.Contains(line, "seq=1") {
+			t.Errorf("%s holds %q", name, line)
+		}
+	}
+}
+
+func TestMidnightRotation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if nil != err {
+		t.Skip(err)
+	}
+	// 23:30 in Tokyo, 14:30 UTC
+	fc := &fakeClock{now: time.Date(2023, 1, 1, 23, 30, 0, 0, tokyo).UTC()}
+	SetClock(fc)
+	defer SetClock(nil)
+
+	dir := t.TempDir()
+	w, err := fileLogWriter(path.Join(dir, "daily.log"), map[string]string{"rotationtime": "24h", "timezone": "Asia/Tokyo"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer w.(io.Closer).Close()
+
+	w.Write([]byte("before midnight\n"))
+	fc.Advance(20 * time.Minute)
+	w.Write([]byte("still the 1st\n"))
+	fc.Advance(20 * time.Minute)
+	w.Write([]byte("after midnight\n"))
+
+	for name, want := range map[string]string{
+		"daily.log-20230101": "before midnight\nstill the 1st\n",
+		"daily.log-20230102": "after midnight\n",
+	} {
+		content, _ := ioutil.ReadFile(path.Join(dir, name))
+		if string(content) != want {
+			t.Errorf("%s holds %q, want %q", name, content, want)
+		}
+	}
+
+	if _, err := fileLogWriter(path.Join(dir, "bad.log"), map[string]string{"timezone": "Nowhere/Special"}); nil == err {
+		t.Error("unknown timezone accepted")
+	}
+}
+
+func TestFilePattern(t *testing.T) {
+	fc := &fakeClock{now: time.Date(2023, 1, 1, 10, 30, 0, 0, time.Local)}
+	SetClock(fc)
+	defer SetClock(nil)
+
+	dir := t.TempDir()
+	w, err := fileLogWriter(path.Join(dir, "minute.log"), map[string]string{"filepattern": "_%Y%m%d%H%M", "rotationtime": "1m"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer w.(io.Closer).Close()
+	w.Write([]byte("first minute\n"))
+	fc.Advance(time.Minute)
+	w.Write([]byte("second minute\n"))
+
+	for _, name := range []string{"minute.log_202301011030", "minute.log_202301011031"} {
+		if _, err := os.Stat(path.Join(dir, name)); nil != err {
+			t.Errorf("expected rotated file %s: %v", name, err)
+		}
+	}
+
+	for _, bad := range []string{"-%H%M", "-%Y%m", "-fixed"} {
+		if _, err := fileLogWriter(path.Join(dir, "bad.log"), map[string]string{"filepattern": bad}); nil == err {
+			t.Errorf("filepattern %q accepted", bad)
+		}
+	}
+}
+
+func TestStaleLinkFile(t *testing.T) {
+	fc := &fakeClock{now: time.Date(2023, 1, 1, 10, 0, 0, 0, time.Local)}
+	SetClock(fc)
+	defer SetClock(nil)
+
+	dir := t.TempDir()
+	link := path.Join(dir, "app.log")
+	ioutil.WriteFile(link, []byte("left by the last run\n"), 0644)
+	w, err := fileLogWriter(link, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	w.Write([]byte("new run\n"))
+	w.(io.Closer).Close()
+
+	if content, _ := ioutil.ReadFile(link); string(content) != "new run\n" {
+		t.Errorf("link reads %q", content)
+	}
+	if content, _ := ioutil.ReadFile(link + ".stale-20230101100000"); string(content) != "left by the last run\n" {
+		t.Errorf("stale file holds %q", content)
+	}
+
+	// a directory cannot be moved aside: run without the link
+	blocked := path.Join(dir, "blocked.log")
+	os.Mkdir(blocked, 0755)
+	w, err = fileLogWriter(blocked, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer w.(io.Closer).Close()
+	if _, err := w.Write([]byte("still written\n")); nil != err {
+		t.Error(err)
+	}
+	if content, _ := ioutil.ReadFile(blocked + "-2023010110"); string(content) != "still written\n" {
+		t.Errorf("rotated file holds %q", content)
+	}
+}
+
+func TestNamedLoggerSkipsStderr(t *testing.T) {
+	defer Snapshot()()
+	// only the hooks of this configuration may receive the standard
+	// logger error, not those an earlier run left behind
+	std := logrus.StandardLogger()
+	savedHooks, savedOut := copyHooks(std.Hooks), std.Out
+	std.ReplaceHooks(make(logrus.LevelHooks))
+	t.Cleanup(func() {
+		std.ReplaceHooks(savedHooks)
+		std.SetOutput(savedOut)
+	})
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{
+		{Tag: "stderr", Type: "file", Level: "error"},
+		{Tag: "remote", Type: "file", Level: "info"},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	GetLogger("remote").Error("remote only")
+	logrus.Error("standard logger error")
+
+	content, _ := ioutil.ReadFile(path.Join(dir, "stderr.log"))
+	if strings.Contains(string(content), "remote only") {
+		t.Errorf("named logger error landed in stderr.log:\n%s", content)
+	}
+	if !strings.Contains(string(content), "standard logger error") {
+		t.Errorf("standard logger error missing from stderr.log:\n%s", content)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer Snapshot()()
+	out := &syncBuffer{}
+	legacy := logrus.New()
+	legacy.SetOutput(out)
+	legacy.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	legacy.SetLevel(logrus.InfoLevel)
+
+	if err := Register("legacy", legacy); nil != err {
+		t.Fatal(err)
+	}
+	if err := Register("legacy", logrus.New()); nil == err {
+		t.Error("registering a taken tag succeeded")
+	}
+	if GetLogger("legacy") != legacy {
+		t.Error("GetLogger does not return the registered logger")
+	}
+	found := false
+	for _, tag := range ListLoggers() {
+		found = found || tag == "legacy"
+	}
+	if !found {
+		t.Errorf("ListLoggers() = %v", ListLoggers())
+	}
+
+	legacy.Debug("before")
+	if err := SetLevel("legacy", logrus.DebugLevel); nil != err {
+		t.Fatal(err)
+	}
+	legacy.Debug("after")
+	if got := strings.Join(out.Lines(), "\n"); strings.Contains(got, "before") || !strings.Contains(got, "msg=after") {
+		t.Errorf("output = %q", got)
+	}
+	if err := SetLevel("no-such-tag", logrus.DebugLevel); nil == err {
+		t.Error("SetLevel of an unknown tag succeeded")
+	}
+}
+
+func TestDuplicateTags(t *testing.T) {
+	defer Snapshot()()
+	err := InitLoggerFromFilters(t.TempDir(), []FilterConfig{
+		{Tag: "twice", Type: "file", Level: "info"},
+		{Tag: "other", Type: "console", Level: "info"},
+		{Tag: "twice", Type: "console", Level: "debug"},
+	})
+	if nil == err {
+		t.Fatal("duplicate tags accepted")
+	}
+	want := "duplicate filter tag twice: level info type file, then level debug type console"
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+	if _, ok := loggers["other"]; ok {
+		t.Error("loggers built despite the duplicate")
+	}
+}
+
+func TestUnknownOutputType(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	conf := path.Join(dir, "log.xml")
+	err := ioutil.WriteFile(conf, []byte(`<logging>
+  <filter enabled="true">
+    <tag>typo</tag>
+    <type>flie</type>
+    <level>INFO</level>
+  </filter>
+</logging>`), 0644)
+	if nil != err {
+		t.Fatal(err)
+	}
+	err = InitLogger(dir, conf)
+	if nil == err || err.Error() != `typo: unknown output type "flie"` {
+		t.Errorf("InitLogger error = %v", err)
+	}
+}
+
+func TestXMLSizeElements(t *testing.T) {
+	filters, err := parseXMLConfig([]byte(`<logging>
+  <filter enabled="true">
+    <tag>elements</tag>
+    <type>file</type>
+    <level>INFO</level>
+    <maxsize>10M</maxsize>
+    <maxbackups>3</maxbackups>
+  </filter>
+  <filter enabled="true">
+    <tag>properties</tag>
+    <type>file</type>
+    <level>INFO</level>
+    <property name="maxsize">20M</property>
+    <property name="maxbackups">4</property>
+  </filter>
+  <filter enabled="true">
+    <tag>both</tag>
+    <type>file</type>
+    <level>INFO</level>
+    <property name="maxsize">20M</property>
+    <maxsize> 30M </maxsize>
+  </filter>
+</logging>`))
+	if nil != err {
+		t.Fatal(err)
+	}
+	want := []map[string]string{
+		{"maxsize": "10M", "maxbackups": "3"},
+		{"maxsize": "20M", "maxbackups": "4"},
+		{"maxsize": "30M"},
+	}
+	for i, fc := range filters {
+		if !reflect.DeepEqual(fc.Properties, want[i]) {
+			t.Errorf("%s: properties %v, want %v", fc.Tag, fc.Properties, want[i])
+		}
+	}
+}
+
+func TestLogAt(t *testing.T) {
+	defer Snapshot()()
+	out := &syncBuffer{}
+	l := newLogger("replay", logrus.InfoLevel, txtFormatter)
+	l.SetOutput(out)
+	loggers["replay"] = l
+
+	at := time.Date(2021, 6, 30, 23, 59, 58, 0, time.Local)
+	LogAt("replay", at).Info("backfilled")
+	line := out.Lines()[0]
+	if want := "[" + at.Format(txtFormatter.TimestampFormat) + "]"; !strings.HasPrefix(line, want) {
+		t.Errorf("%q does not start with %s", line, want)
+	}
+}
+
+func TestInitLoggerWithDefault(t *testing.T) {
+	defer Snapshot()()
+	defaults := []byte(`<logging>
+  <filter enabled="true">
+    <tag>api</tag>
+    <type>file</type>
+    <level>INFO</level>
+  </filter>
+  <filter enabled="true">
+    <tag>db</tag>
+    <type>file</type>
+    <level>WARNING</level>
+  </filter>
+</logging>`)
+	dir := t.TempDir()
+	conf := path.Join(dir, "log.xml")
+
+	if err := InitLoggerWithDefault(dir, conf, defaults); nil != err {
+		t.Fatalf("without a file: %v", err)
+	}
+	if lv := GetLogger("db").GetLevel(); lv != logrus.WarnLevel {
+		t.Errorf("default db level %s", lv)
+	}
+	Close()
+
+	err := ioutil.WriteFile(conf, []byte(`<logging>
+  <filter enabled="true">
+    <tag>db</tag>
+    <type>file</type>
+    <level>DEBUG</level>
+  </filter>
+  <filter enabled="true">
+    <tag>jobs</tag>
+    <type>file</type>
+    <level>ERROR</level>
+  </filter>
+</logging>`), 0644)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := InitLoggerWithDefault(dir, conf, defaults); nil != err {
+		t.Fatal(err)
+	}
+	defer Close()
+	for tag, want := range map[string]logrus.Level{"api": logrus.InfoLevel, "db": logrus.DebugLevel, "jobs": logrus.ErrorLevel} {
+		if l, ok := GetLoggerByPrefix(tag); !ok {
+			t.Errorf("%s not configured", tag)
+		} else if lv := l.GetLevel(); lv != want {
+			t.Errorf("%s level %s, want %s", tag, lv, want)
+		}
+	}
+
+	if err := InitLoggerWithDefault(dir, "", []byte("<logging")); nil == err {
+		t.Error("broken defaults accepted")
+	}
+}
@@ -0,0 +1,56 @@
+// Package logxtest holds the logx helpers for tests, apart so that
+// programs importing logx do not link the testing package.
+package logxtest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/blackbeans/logx"
+)
+
+// testLogWriter forwards each written line to t.Log until it is stopped,
+// since t.Log panics once the test has completed
+type testLogWriter struct {
+	mu      sync.Mutex
+	t       testing.TB
+	stopped bool
+}
+
+func (w *testLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.stopped {
+		w.t.Helper()
+		w.t.Log(strings.TrimRight(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+// RedirectToTestLog sends the output of the logger logx.GetLogger resolves
+// for tag to t.Log, so it shows up attributed to the test, until restore is
+// called or the test ends:
+//
+//	defer logxtest.RedirectToTestLog(t, "db")()
+//
+// Loggers with several outputs or split levels are redirected as a whole,
+// see logx.RedirectOutput. Loggers are shared, so parallel tests
+// redirecting the same tag see each other's lines; restore only undoes its
+// own redirect.
+func RedirectToTestLog(t testing.TB, tag string) (restore func()) {
+	w := &testLogWriter{t: t}
+	undo := logx.RedirectOutput(tag, w)
+
+	var once sync.Once
+	restore = func() {
+		once.Do(func() {
+			w.mu.Lock()
+			w.stopped = true
+			w.mu.Unlock()
+			undo()
+		})
+	}
+	t.Cleanup(restore)
+	return restore
+}
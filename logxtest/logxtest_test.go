@@ -0,0 +1,66 @@
+package logxtest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/blackbeans/logx"
+)
+
+// recordingTB captures what reaches t.Log
+type recordingTB struct {
+	testing.TB
+	logged []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Log(args ...interface{}) {
+	r.logged = append(r.logged, fmt.Sprint(args...))
+}
+
+func (r *recordingTB) Cleanup(func()) {}
+
+func TestRedirectToTestLog(t *testing.T) {
+	defer logx.Snapshot()()
+	dir := t.TempDir()
+	err := logx.InitLoggerFromFilters(dir, []logx.FilterConfig{
+		{Tag: "svc", Type: "file", Level: "info"},
+		{Tag: "split", Type: "file", Level: "info", Properties: map[string]string{"splitlevels": "true"}},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	for _, tag := range []string{"svc", "split"} {
+		l := logx.GetLogger(tag)
+		tb := &recordingTB{TB: t}
+		restore := RedirectToTestLog(tb, tag)
+		l.Info("during the test")
+		restore()
+		l.Info("after restore")
+
+		if len(tb.logged) != 1 || !strings.Contains(tb.logged[0], "during the test") || strings.HasSuffix(tb.logged[0], "\n") {
+			t.Errorf("%s: t.Log got %q", tag, tb.logged)
+		}
+	}
+
+	// with the real testing.T, cleanup restores too
+	t.Run("cleanup", func(t *testing.T) {
+		RedirectToTestLog(t, "svc")
+		logx.GetLogger("svc").Info("shown under the subtest")
+	})
+	logx.GetLogger("svc").Info("back after cleanup")
+	logx.Flush()
+
+	for name, want := range map[string]int{"svc.log": 2, "split.info.log": 1} {
+		content, _ := ioutil.ReadFile(path.Join(dir, name))
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		if len(lines) != want || strings.Contains(string(content), "during the test") || strings.Contains(string(content), "subtest") {
+			t.Errorf("%s holds %q", name, content)
+		}
+	}
+}
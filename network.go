@@ -0,0 +1,142 @@
+package logx
+
+import (
+	"compress/gzip"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultDialTimeout = 3 * time.Second
+
+// netWriter sends every write to a network collector, dialing lazily and
+// redialing after a failed write.
+//
+// With compression on, each connection carries one gzip stream: the header
+// goes out with the first entry and the trailer on Close. Entries are sync
+// flushed at most flushinterval after they are written (and on Flush), so
+// the collector can decode everything received so far without waiting for
+// the stream to end. A redial starts a new stream.
+type netWriter struct {
+	mu       sync.Mutex
+	network  string
+	address  string
+	timeout  time.Duration
+	conn     net.Conn
+	compress bool
+	interval time.Duration
+	gz       *gzip.Writer
+	pending  *time.Timer
+}
+
+// networkLogWriter builds the writer of a "network" output from its
+// address, network (tcp by default), compress and flushinterval properties
+func networkLogWriter(props map[string]string) (*netWriter, error) {
+	address := props["address"]
+	if address == "" {
+		return nil, errors.New("network output needs an address property")
+	}
+	network := props["network"]
+	if network == "" {
+		network = "tcp"
+	}
+	w := &netWriter{network: network, address: address, timeout: defaultDialTimeout}
+	if v, _ := strconv.ParseBool(props["compress"]); v || props["compress"] == "gzip" {
+		w.compress = true
+		interval, err := time.ParseDuration(props["flushinterval"])
+		if nil != err || interval <= 0 {
+			interval = defaultFlushInterval
+		}
+		w.interval = interval
+	}
+	return w, nil
+}
+
+func (w *netWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if nil == w.conn {
+		conn, err := net.DialTimeout(w.network, w.address, w.timeout)
+		if nil != err {
+			return 0, err
+		}
+		w.conn = conn
+		if w.compress {
+			w.gz = gzip.NewWriter(conn)
+		}
+	}
+	if !w.compress {
+		n, err := w.conn.Write(p)
+		if nil != err {
+			w.reset()
+		}
+		return n, err
+	}
+	n, err := w.gz.Write(p)
+	if nil != err {
+		w.reset()
+		return n, err
+	}
+	if nil == w.pending {
+		w.pending = time.AfterFunc(w.interval, func() { w.Flush() })
+	}
+	return n, nil
+}
+
+// Flush pushes the entries held by the compressor to the collector
+func (w *netWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+func (w *netWriter) flush() error {
+	if nil != w.pending {
+		w.pending.Stop()
+		w.pending = nil
+	}
+	if nil == w.gz {
+		return nil
+	}
+	err := w.gz.Flush()
+	if nil != err {
+		w.reset()
+	}
+	return err
+}
+
+// reset drops a broken connection so the next write redials
+func (w *netWriter) reset() {
+	if nil != w.pending {
+		w.pending.Stop()
+		w.pending = nil
+	}
+	w.conn.Close()
+	w.conn = nil
+	w.gz = nil
+}
+
+func (w *netWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if nil == w.conn {
+		return nil
+	}
+	var err error
+	if nil != w.gz {
+		// ends the gzip stream with its trailer
+		err = w.gz.Close()
+	}
+	if cerr := w.conn.Close(); nil == err {
+		err = cerr
+	}
+	if nil != w.pending {
+		w.pending.Stop()
+		w.pending = nil
+	}
+	w.conn = nil
+	w.gz = nil
+	return err
+}
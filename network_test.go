@@ -0,0 +1,61 @@
+package logx
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNetworkCompress(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	received := make(chan string, 10)
+	go func() {
+		conn, err := lis.Accept()
+		if nil != err {
+			return
+		}
+		defer conn.Close()
+		gz, err := gzip.NewReader(conn)
+		if nil != err {
+			return
+		}
+		scanner := bufio.NewScanner(gz)
+		for scanner.Scan() {
+			received <- scanner.Text()
+		}
+	}()
+
+	w, err := networkLogWriter(map[string]string{
+		"address":       lis.Addr().String(),
+		"compress":      "true",
+		"flushinterval": "20ms",
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	want := []string{"first line", "second line"}
+	for _, line := range want {
+		if _, err := w.Write([]byte(line + "\n")); nil != err {
+			t.Fatal(err)
+		}
+	}
+	// no Flush: the periodic flush must deliver the lines on its own
+	for _, line := range want {
+		select {
+		case got := <-received:
+			if got != line {
+				t.Errorf("decompressed %q, want %q", got, line)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%q never arrived", line)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package logx
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NormalizeDurations registers a transform rendering every time.Duration
+// field as a float64 count of unit, e.g. NormalizeDurations(time.Millisecond)
+// turns 1500*time.Microsecond into 1.5, so latency fields are uniformly
+// numeric.
+func NormalizeDurations(unit time.Duration) {
+	if unit <= 0 {
+		unit = time.Millisecond
+	}
+	AddTransform(func(e *logrus.Entry) *logrus.Entry {
+		for k, v := range e.Data {
+			if d, ok := v.(time.Duration); ok {
+				e.Data[k] = float64(d) / float64(unit)
+			}
+		}
+		return e
+	})
+}
+
+// NormalizeTimes registers a transform rendering every time.Time field with
+// layout, or with the package timestamp layout when layout is empty.
+func NormalizeTimes(layout string) {
+	if layout == "" {
+		layout = txtFormatter.TimestampFormat
+	}
+	AddTransform(func(e *logrus.Entry) *logrus.Entry {
+		for k, v := range e.Data {
+			if t, ok := v.(time.Time); ok {
+				e.Data[k] = t.Format(layout)
+			}
+		}
+		return e
+	})
+}
+
+// byteBase64 is 1 when []byte fields render as base64 rather than hex
+var byteBase64 int32
+
+// SetByteEncoding picks how every formatter renders []byte field values:
+// "hex", the default, or "base64" (standard encoding, padded).
+func SetByteEncoding(encoding string) error {
+	switch encoding {
+	case "hex":
+		atomic.StoreInt32(&byteBase64, 0)
+	case "base64":
+		atomic.StoreInt32(&byteBase64, 1)
+	default:
+		return fmt.Errorf("byte encoding %q is neither hex nor base64", encoding)
+	}
+	return nil
+}
+
+// encodeBytes renders the []byte fields of e as strings, on a copy when
+// there are any
+func encodeBytes(e *logrus.Entry) *logrus.Entry {
+	copied := false
+	for k, v := range e.Data {
+		b, ok := v.([]byte)
+		if !ok {
+			continue
+		}
+		if !copied {
+			e, copied = cloneEntry(e), true
+		}
+		if atomic.LoadInt32(&byteBase64) == 1 {
+			e.Data[k] = base64.StdEncoding.EncodeToString(b)
+		} else {
+			e.Data[k] = hex.EncodeToString(b)
+		}
+	}
+	return e
+}
+
+// multilineMode is how messages and string fields spanning several lines
+// are written: multilineRaw, multilineEscape or multilineIndent
+var multilineMode int32
+
+const (
+	multilineRaw int32 = iota
+	multilineEscape
+	multilineIndent
+)
+
+var escapeNewlines = strings.NewReplacer("\r\n", `\n`, "\n", `\n`, "\r", `\r`)
+var indentNewlines = strings.NewReplacer("\r\n", "\n\t", "\n", "\n\t")
+
+// SetMultilineMode picks how every formatter receives messages and string
+// fields holding newlines: "raw", the default, leaves them alone, "escape"
+// writes each newline as \n so every entry stays one physical line for
+// line oriented parsers, and "indent" starts continuation lines with a tab
+// so they read as part of the entry. JSON output is single line already.
+func SetMultilineMode(mode string) error {
+	switch mode {
+	case "raw":
+		atomic.StoreInt32(&multilineMode, multilineRaw)
+	case "escape":
+		atomic.StoreInt32(&multilineMode, multilineEscape)
+	case "indent":
+		atomic.StoreInt32(&multilineMode, multilineIndent)
+	default:
+		return fmt.Errorf("multiline mode %q is not raw, escape or indent", mode)
+	}
+	return nil
+}
+
+// foldLines applies the multiline mode to e, on a copy when anything
+// changes
+func foldLines(e *logrus.Entry) *logrus.Entry {
+	var r *strings.Replacer
+	switch atomic.LoadInt32(&multilineMode) {
+	case multilineEscape:
+		r = escapeNewlines
+	case multilineIndent:
+		r = indentNewlines
+	default:
+		return e
+	}
+	copied := false
+	edit := func() {
+		if !copied {
+			e, copied = cloneEntry(e), true
+		}
+	}
+	if strings.ContainsAny(e.Message, "\r\n") {
+		edit()
+		e.Message = r.Replace(strings.TrimRight(e.Message, "\r\n"))
+	}
+	for k, v := range e.Data {
+		if s, ok := v.(string); ok && strings.ContainsAny(s, "\r\n") {
+			edit()
+			e.Data[k] = r.Replace(s)
+		}
+	}
+	return e
+}
@@ -0,0 +1,95 @@
+package logx
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNormalizeDurationsAndTimes(t *testing.T) {
+	keepTransforms(t)
+	NormalizeDurations(time.Millisecond)
+	NormalizeTimes("")
+
+	out := &syncBuffer{}
+	l := newTestLogger("normalize", out)
+	l.WithFields(logrus.Fields{
+		"latency": 1500 * time.Microsecond,
+		"started": time.Date(2023, 1, 1, 10, 0, 0, 0, time.Local),
+		"count":   3,
+	}).Info("done")
+
+	line := out.Lines()[0]
+	for _, want := range []string{"latency=1.5 ", `started="2023-01-01.10:00:00"`, "count=3"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("%q is missing %q", line, want)
+		}
+	}
+}
+
+func TestSetByteEncoding(t *testing.T) {
+	defer SetByteEncoding("hex")
+	nonce := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	for _, tc := range []struct {
+		encoding string
+		text     string
+		json     string
+	}{
+		{"hex", "nonce=deadbeef01", `"nonce":"deadbeef01"`},
+		{"base64", `nonce="3q2+7wE="`, `"nonce":"3q2+7wE="`},
+	} {
+		if err := SetByteEncoding(tc.encoding); nil != err {
+			t.Fatal(err)
+		}
+		out := &syncBuffer{}
+		l := newTestLogger("bytes", out)
+		e := l.WithField("nonce", nonce)
+		e.Info("text")
+		l.SetFormatter(newEntryFormatter("bytes", &logrus.JSONFormatter{}))
+		e.Info("json")
+		lines := out.Lines()
+		if !strings.Contains(lines[0], tc.text) {
+			t.Errorf("%s: text line %q, want %q", tc.encoding, lines[0], tc.text)
+		}
+		if !strings.Contains(lines[1], tc.json) {
+			t.Errorf("%s: json line %q, want %q", tc.encoding, lines[1], tc.json)
+		}
+		if _, ok := e.Data["nonce"].([]byte); !ok {
+			t.Errorf("%s: the entry itself was rewritten", tc.encoding)
+		}
+	}
+	if err := SetByteEncoding("base32"); nil == err {
+		t.Error("unknown encoding accepted")
+	}
+}
+
+func TestSetMultilineMode(t *testing.T) {
+	defer SetMultilineMode("raw")
+	trace := "panic: boom\n\ngoroutine 1 [running]:\nmain.main()"
+	for _, tc := range []struct {
+		mode  string
+		lines int
+		want  string
+	}{
+		{"raw", 4, "goroutine 1 [running]:"},
+		{"escape", 1, `panic: boom\n\ngoroutine 1 [running]:\nmain.main()`},
+		{"indent", 4, "\tmain.main()"},
+	} {
+		if err := SetMultilineMode(tc.mode); nil != err {
+			t.Fatal(err)
+		}
+		out := &syncBuffer{}
+		l := newTestLogger("multiline", out)
+		l.SetFormatter(newEntryFormatter("multiline", txtFormatter))
+		l.Error(trace)
+		lines := out.Lines()
+		if len(lines) != tc.lines || !strings.Contains(strings.Join(lines, "\n"), tc.want) {
+			t.Errorf("%s: got %d lines %q, want %d with %q", tc.mode, len(lines), lines, tc.lines, tc.want)
+		}
+	}
+	if err := SetMultilineMode("fold"); nil == err {
+		t.Error("unknown mode accepted")
+	}
+}
@@ -0,0 +1,40 @@
+package logx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fatalExit reads the onfatal property of the filter tag, choosing what
+// Fatal does after the entry is written:
+//
+//	exit      terminate the process, the default
+//	panic     panic with a string naming the tag, so that a worker
+//	          goroutine can recover and stop alone
+//	continue  return to the caller
+//
+// It returns the ExitFunc of the logger, nil for exit. The action runs
+// where logrus would exit rather than in a hook, as hooks fire before the
+// entry reaches the logger output. Handlers registered with
+// logrus.RegisterExitHandler still run.
+func fatalExit(tag string, props map[string]string) (func(int), error) {
+	switch v := strings.TrimSpace(props["onfatal"]); v {
+	case "", "exit":
+		return nil, nil
+	case "panic":
+		return func(code int) {
+			panic(fmt.Sprintf("logx: fatal entry on %s, exit code %d", tag, code))
+		}, nil
+	case "continue":
+		return func(int) {}, nil
+	default:
+		return nil, fmt.Errorf("onfatal %q is none of exit, panic and continue", v)
+	}
+}
+
+// ownsExit reports whether the filter props keep their own Fatal action
+// over SetExitFunc
+func ownsExit(props map[string]string) bool {
+	v := strings.TrimSpace(props["onfatal"])
+	return v != "" && v != "exit"
+}
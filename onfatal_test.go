@@ -0,0 +1,50 @@
+package logx
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestOnFatal(t *testing.T) {
+	defer Snapshot()()
+	exited := false
+	SetExitFunc(func(int) { exited = true })
+	defer SetExitFunc(nil)
+
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{
+		{Tag: "worker", Type: "file", Level: "info", Properties: map[string]string{"onfatal": "panic"}},
+		{Tag: "lenient", Type: "file", Level: "info", Properties: map[string]string{"onfatal": "continue"}},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	SetExitFunc(func(int) { exited = true })
+
+	recovered := func() (r interface{}) {
+		defer func() { r = recover() }()
+		GetLogger("worker").Fatal("worker gave up")
+		return nil
+	}()
+	if nil == recovered || !strings.Contains(recovered.(string), "worker") {
+		t.Fatalf("recovered %v, want a panic naming worker", recovered)
+	}
+	GetLogger("lenient").Fatal("carry on")
+	if exited {
+		t.Error("exit func called despite onfatal")
+	}
+	Flush()
+	b, err := ioutil.ReadFile(path.Join(dir, "worker.log"))
+	if nil != err || !strings.Contains(string(b), "worker gave up") {
+		t.Errorf("fatal entry not written before the panic: %q, %v", b, err)
+	}
+
+	err = InitLoggerFromFilters(dir, []FilterConfig{
+		{Tag: "bad", Type: "file", Level: "info", Properties: map[string]string{"onfatal": "abort"}},
+	})
+	if nil == err {
+		t.Error("unknown onfatal accepted")
+	}
+}
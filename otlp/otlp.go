@@ -0,0 +1,227 @@
+// Package otlp is the "otlp" output type of logx: entries are exported as
+// OpenTelemetry log records, batched and retried by the OpenTelemetry SDK
+// and sent with its OTLP/HTTP exporter. Register it before InitLogger:
+//
+//	logx.RegisterOutputType("otlp", otlp.Open, otlp.Describe)
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scope is the instrumentation scope of the exported records
+const scope = "github.com/blackbeans/logx"
+
+// Open builds the writer of an "otlp" output, exporting to the OTLP/HTTP
+// endpoint property: the collector base such as http://otel:4318, or its
+// full /v1/logs URL. headers adds request headers as name=value pairs
+// separated by commas, and servicename (the tag by default) is the
+// service.name of the resource. Records are sent in batches of batchsize
+// (512) or every flushinterval (1s); the exporter retries network errors,
+// 429 and 5xx answers with backoff.
+func Open(tag string, props map[string]string) (io.Writer, error) {
+	endpoint := endpointURL(props)
+	if endpoint == "" {
+		return nil, errors.New("otlp output needs an endpoint property")
+	}
+	opts := []otlploghttp.Option{otlploghttp.WithEndpointURL(endpoint)}
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(props["headers"], ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("otlp headers %q: %q is not name=value", props["headers"], pair)
+		}
+		headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	if len(headers) > 0 {
+		opts = append(opts, otlploghttp.WithHeaders(headers))
+	}
+
+	var batch []sdklog.BatchProcessorOption
+	if v, ok := props["batchsize"]; ok {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if nil != err || n < 1 {
+			return nil, fmt.Errorf("batchsize %q is not a positive number", v)
+		}
+		batch = append(batch, sdklog.WithExportMaxBatchSize(n))
+	}
+	if v, ok := props["flushinterval"]; ok {
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if nil != err || d <= 0 {
+			return nil, fmt.Errorf("flushinterval %q is not a positive duration", v)
+		}
+		batch = append(batch, sdklog.WithExportInterval(d))
+	}
+	service := tag
+	if v := strings.TrimSpace(props["servicename"]); v != "" {
+		service = v
+	}
+
+	exporter, err := otlploghttp.New(context.Background(), opts...)
+	if nil != err {
+		return nil, fmt.Errorf("otlp exporter: %w", err)
+	}
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithResource(resource.NewSchemaless(attribute.String("service.name", service))),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter, batch...)),
+	)
+	return &writer{provider: provider, logger: provider.Logger(scope)}, nil
+}
+
+// Describe names the destination of an "otlp" output for
+// logx.LogConfigSummary, e.g. "otlp http://otel:4318/v1/logs"
+func Describe(tag string, props map[string]string) string {
+	return "otlp " + endpointURL(props)
+}
+
+// endpointURL is the /v1/logs URL of the endpoint property, empty without
+// one
+func endpointURL(props map[string]string) string {
+	endpoint := strings.TrimSpace(props["endpoint"])
+	if endpoint == "" || strings.HasSuffix(endpoint, "/v1/logs") {
+		return endpoint
+	}
+	return strings.TrimSuffix(endpoint, "/") + "/v1/logs"
+}
+
+// writer hands entries to the SDK, which exports them from its own
+// goroutine, so the logging goroutine never waits for the collector
+type writer struct {
+	provider *sdklog.LoggerProvider
+	logger   log.Logger
+}
+
+func (w *writer) WriteEntry(e *logrus.Entry, p []byte) (int, error) {
+	ctx := context.Background()
+	if sc := spanContext(e.Data); sc.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, sc)
+	}
+	w.logger.Emit(ctx, record(e, time.Now()))
+	// Fatal exits right after writing; export what is batched first
+	if e.Level <= logrus.FatalLevel {
+		return len(p), w.Flush()
+	}
+	return len(p), nil
+}
+
+// Write exports p as the body of a record without severity
+func (w *writer) Write(p []byte) (int, error) {
+	var r log.Record
+	now := time.Now()
+	r.SetTimestamp(now)
+	r.SetObservedTimestamp(now)
+	r.SetBody(attribute.StringValue(strings.TrimRight(string(p), "\n")))
+	w.logger.Emit(context.Background(), r)
+	return len(p), nil
+}
+
+// Flush exports the batched records
+func (w *writer) Flush() error {
+	return w.provider.ForceFlush(context.Background())
+}
+
+// Close exports what is batched and stops the exporter
+func (w *writer) Close() error {
+	return w.provider.Shutdown(context.Background())
+}
+
+// severities maps logrus levels to the OpenTelemetry severity numbers
+// TRACE, DEBUG, INFO, WARN, ERROR, FATAL and, for Panic, FATAL4
+var severities = map[logrus.Level]log.Severity{
+	logrus.TraceLevel: log.SeverityTrace,
+	logrus.DebugLevel: log.SeverityDebug,
+	logrus.InfoLevel:  log.SeverityInfo,
+	logrus.WarnLevel:  log.SeverityWarn,
+	logrus.ErrorLevel: log.SeverityError,
+	logrus.FatalLevel: log.SeverityFatal,
+	logrus.PanicLevel: log.SeverityFatal4,
+}
+
+// record converts e to a log record: the message is the body and fields
+// are attributes, sorted by key, except the trace_id and span_id fields of
+// logx.WithTraceparent which carry the trace context
+func record(e *logrus.Entry, observed time.Time) log.Record {
+	var r log.Record
+	r.SetTimestamp(e.Time)
+	r.SetObservedTimestamp(observed)
+	r.SetSeverity(severities[e.Level])
+	r.SetSeverityText(strings.ToUpper(e.Level.String()))
+	r.SetBody(attribute.StringValue(e.Message))
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		if k != "trace_id" && k != "span_id" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		r.AddAttributes(attribute.KeyValue{Key: attribute.Key(k), Value: value(e.Data[k])})
+	}
+	return r
+}
+
+func value(v interface{}) attribute.Value {
+	switch t := v.(type) {
+	case string:
+		return attribute.StringValue(t)
+	case bool:
+		return attribute.BoolValue(t)
+	case int:
+		return attribute.IntValue(t)
+	case int8:
+		return attribute.Int64Value(int64(t))
+	case int16:
+		return attribute.Int64Value(int64(t))
+	case int32:
+		return attribute.Int64Value(int64(t))
+	case int64:
+		return attribute.Int64Value(t)
+	case uint8:
+		return attribute.Int64Value(int64(t))
+	case uint16:
+		return attribute.Int64Value(int64(t))
+	case uint32:
+		return attribute.Int64Value(int64(t))
+	case float32:
+		return attribute.Float64Value(float64(t))
+	case float64:
+		return attribute.Float64Value(t)
+	case []byte:
+		return attribute.ByteSliceValue(t)
+	case error:
+		return attribute.StringValue(t.Error())
+	}
+	return attribute.StringValue(fmt.Sprint(v))
+}
+
+// spanContext reads the trace_id and span_id fields; it is invalid when
+// either is missing or malformed
+func spanContext(data logrus.Fields) trace.SpanContext {
+	traceID, err := trace.TraceIDFromHex(fmt.Sprint(data["trace_id"]))
+	if nil != err {
+		return trace.SpanContext{}
+	}
+	spanID, err := trace.SpanIDFromHex(fmt.Sprint(data["span_id"]))
+	if nil != err {
+		return trace.SpanContext{}
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+}
@@ -0,0 +1,147 @@
+package otlp
+
+import (
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blackbeans/logx"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRecord(t *testing.T) {
+	severities := map[logrus.Level]log.Severity{
+		logrus.TraceLevel: 1, logrus.DebugLevel: 5, logrus.InfoLevel: 9,
+		logrus.WarnLevel: 13, logrus.ErrorLevel: 17, logrus.FatalLevel: 21, logrus.PanicLevel: 24,
+	}
+	for level, want := range severities {
+		r := record(&logrus.Entry{Level: level, Data: logrus.Fields{}}, time.Now())
+		if r.Severity() != want {
+			t.Errorf("%s maps to severity %d, want %d", level, r.Severity(), want)
+		}
+	}
+
+	at := time.Unix(1700000000, 42)
+	e := &logrus.Entry{Level: logrus.WarnLevel, Time: at, Message: "slow query", Data: logrus.Fields{
+		"user": "ada", "rows": 12, "cached": false, "ms": 1.5, "err": errors.New("timeout"),
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "span_id": "00f067aa0ba902b7",
+	}}
+	r := record(e, at)
+	if !r.Timestamp().Equal(at) || r.SeverityText() != "WARNING" || r.Body().AsString() != "slow query" {
+		t.Errorf("record at %v with %s %q", r.Timestamp(), r.SeverityText(), r.Body().AsString())
+	}
+	want := []attribute.KeyValue{
+		attribute.Bool("cached", false), attribute.String("err", "timeout"),
+		attribute.Float64("ms", 1.5), attribute.Int("rows", 12), attribute.String("user", "ada"),
+	}
+	var got []attribute.KeyValue
+	r.WalkAttributes(func(kv attribute.KeyValue) bool {
+		got = append(got, kv)
+		return true
+	})
+	if len(got) != len(want) {
+		t.Fatalf("attributes %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("attribute %v, want %v", got[i], want[i])
+		}
+	}
+
+	sc := spanContext(e.Data)
+	if sc.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("span context %v", sc)
+	}
+	if spanContext(logrus.Fields{"trace_id": "nope"}).IsValid() {
+		t.Error("malformed trace_id accepted")
+	}
+}
+
+func TestExport(t *testing.T) {
+	var mu sync.Mutex
+	var records []*logspb.ResourceLogs
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Authorization") != "Bearer k" {
+			t.Errorf("export to %s with Authorization %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req collogspb.ExportLogsServiceRequest
+		if err := proto.Unmarshal(b, &req); nil != err {
+			t.Error(err)
+		}
+		mu.Lock()
+		records = append(records, req.ResourceLogs...)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer srv.Close()
+
+	if err := logx.RegisterOutputType("otlp", Open, Describe); nil != err {
+		t.Fatal(err)
+	}
+	err := logx.InitLoggerFromFilters(t.TempDir(), []logx.FilterConfig{{Tag: "api", Type: "otlp", Level: "info", Properties: map[string]string{
+		"endpoint": srv.URL, "headers": "Authorization=Bearer k", "servicename": "checkout",
+	}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer logx.Close()
+	logx.WithTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "api").WithField("user", "ada").Info("paid")
+	if err := logx.Flush(); nil != err {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 1 || len(records[0].ScopeLogs) != 1 || len(records[0].ScopeLogs[0].LogRecords) != 1 {
+		t.Fatalf("exported %v", records)
+	}
+	if attrs := records[0].Resource.Attributes; len(attrs) != 1 || attrs[0].Key != "service.name" || attrs[0].Value.GetStringValue() != "checkout" {
+		t.Errorf("resource %v", attrs)
+	}
+	lr := records[0].ScopeLogs[0].LogRecords[0]
+	if lr.Body.GetStringValue() != "paid" || lr.SeverityNumber != logspb.SeverityNumber_SEVERITY_NUMBER_INFO {
+		t.Errorf("record %v", lr)
+	}
+	if hex.EncodeToString(lr.TraceId) != "4bf92f3577b34da6a3ce929d0e0e4736" || hex.EncodeToString(lr.SpanId) != "00f067aa0ba902b7" {
+		t.Errorf("trace context %x %x", lr.TraceId, lr.SpanId)
+	}
+	if len(lr.Attributes) != 1 || lr.Attributes[0].Key != "user" || lr.Attributes[0].Value.GetStringValue() != "ada" {
+		t.Errorf("attributes %v", lr.Attributes)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	for endpoint, want := range map[string]string{
+		"http://otel:4318":          "otlp http://otel:4318/v1/logs",
+		"http://otel:4318/":         "otlp http://otel:4318/v1/logs",
+		"https://otel/base/v1/logs": "otlp https://otel/base/v1/logs",
+	} {
+		if got := Describe("api", map[string]string{"endpoint": endpoint}); got != want {
+			t.Errorf("%s described as %q, want %q", endpoint, got, want)
+		}
+	}
+}
+
+func TestOpenProperties(t *testing.T) {
+	for _, props := range []map[string]string{
+		{},
+		{"endpoint": "http://otel:4318", "headers": "Authorization"},
+		{"endpoint": "http://otel:4318", "batchsize": "0"},
+		{"endpoint": "http://otel:4318", "flushinterval": "soon"},
+	} {
+		if _, err := Open("api", props); nil == err {
+			t.Errorf("%v accepted", props)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package logx
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rifflock/lfshook"
+	"github.com/sirupsen/logrus"
+)
+
+// buildOutput opens the destination of one output type, behind its
+// fallback file if any. Unknown types are an error, as a logger left on its
+// default output would write nowhere useful.
+func buildOutput(l *logrus.Logger, logPath string, tag string, typ string, props map[string]string) (io.Writer, error) {
+	w, err := primaryOutput(l, logPath, tag, typ, props)
+	if nil != err {
+		return nil, err
+	}
+	return fallbackOutput(w, logPath, tag, props)
+}
+
+func primaryOutput(l *logrus.Logger, logPath string, tag string, typ string, props map[string]string) (io.Writer, error) {
+	switch typ {
+	case "console":
+		return consoleOutput(l, props), nil
+	case "file":
+		if split, _ := strconv.ParseBool(props["splitlevels"]); split {
+			return splitOutput(logPath, tag, props)
+		}
+		if props["filetemplate"] != "" {
+			return templateOutput(logPath, tag, props)
+		}
+		if n, _ := strconv.Atoi(props["shards"]); n > 1 {
+			return shardOutput(l, logPath, tag, n, props)
+		}
+		return fileOutput(l, path.Join(logPath, tag+".log"), tag, props)
+	case "network":
+		nw, err := networkLogWriter(props)
+		if nil != err {
+			return nil, fmt.Errorf("%s: %w", tag, err)
+		}
+		w, err := overflowOutput(nw, props)
+		if nil == err {
+			w, err = ttlOutput(w, props)
+		}
+		if nil == err {
+			w = countDrops(w, tag)
+		}
+		if nil == err {
+			w, err = recordOutput(w, props)
+		}
+		if nil != err {
+			return nil, fmt.Errorf("%s: %w", tag, err)
+		}
+		return w, nil
+	case "syslog":
+		w, err := syslogOutput(props)
+		if nil != err {
+			return nil, fmt.Errorf("%s: %w", tag, err)
+		}
+		return w, nil
+	case "eventlog":
+		w, err := eventLogOutput(props)
+		if nil != err {
+			return nil, fmt.Errorf("%s: %w", tag, err)
+		}
+		return w, nil
+	}
+	outputTypesLock.Lock()
+	ot, ok := outputTypes[typ]
+	outputTypesLock.Unlock()
+	if ok {
+		w, err := ot.open(tag, props)
+		if nil != err {
+			return nil, fmt.Errorf("%s: %w", tag, err)
+		}
+		return w, nil
+	}
+	return nil, fmt.Errorf("%s: unknown output type %q", tag, typ)
+}
+
+// outputType is an output type added by RegisterOutputType
+type outputType struct {
+	open     func(tag string, props map[string]string) (io.Writer, error)
+	describe func(tag string, props map[string]string) string
+}
+
+var outputTypesLock sync.Mutex
+var outputTypes = make(map[string]outputType)
+
+// RegisterOutputType adds the output type typ, whose writers open builds
+// from the tag and properties of a filter, for outputs kept out of logx
+// with their dependencies, such as the otlp module:
+//
+//	logx.RegisterOutputType("otlp", otlp.Open, otlp.Describe)
+//
+// describe names the destination in LogConfigSummary, e.g. "otlp
+// http://otel:4318"; when nil the type name is used. Writers implementing
+// WriteEntry(*logrus.Entry, []byte) (int, error) get every entry along
+// with its rendering; Flush and Close call their Flush() error and Close()
+// error methods. Call it before InitLogger; the built in types cannot be
+// replaced.
+func RegisterOutputType(typ string, open func(tag string, props map[string]string) (io.Writer, error), describe func(tag string, props map[string]string) string) error {
+	switch typ {
+	case "console", "file", "network", "syslog", "eventlog":
+		return fmt.Errorf("output type %q is built in", typ)
+	}
+	outputTypesLock.Lock()
+	defer outputTypesLock.Unlock()
+	outputTypes[typ] = outputType{open: open, describe: describe}
+	return nil
+}
+
+// fileOutput opens a rotating file with the buffering and overflow
+// properties applied
+func fileOutput(l *logrus.Logger, filename string, tag string, props map[string]string) (io.Writer, error) {
+	if err := preflight(filename); nil != err {
+		return nil, fmt.Errorf("%s: %w", tag, err)
+	}
+	rotate, err := fileLogWriter(filename, props)
+	if nil != err {
+		return nil, err
+	}
+	rotate = &diskFullWriter{out: rotate, name: filename, props: props}
+	w, err := overflowOutput(bufferOutput(l, rotate, props, fatalLevels), props)
+	if nil == err {
+		w, err = writeTimeoutOutput(w, props)
+	}
+	if nil == err {
+		w, err = recordOutput(countDrops(w, tag), props)
+	}
+	if nil != err {
+		return nil, fmt.Errorf("%s: %w", tag, err)
+	}
+	return w, nil
+}
+
+// shardOutput opens n files, tag.0.log to tag.<n-1>.log, and spreads the
+// entries over them in turn
+func shardOutput(l *logrus.Logger, logPath string, tag string, n int, props map[string]string) (io.Writer, error) {
+	s := &shardWriter{shards: make(outputGroup, 0, n)}
+	for i := 0; i < n; i++ {
+		w, err := fileOutput(l, path.Join(logPath, fmt.Sprintf("%s.%d.log", tag, i)), tag, props)
+		if nil != err {
+			s.Close()
+			return nil, err
+		}
+		s.shards = append(s.shards, w)
+	}
+	return s, nil
+}
+
+// shardWriter writes each entry to the next of its shards, round-robin, to
+// spread the I/O of a busy logger. Entries keep their order within a shard
+// but not across shards, so readers merging them must sort by time.
+type shardWriter struct {
+	next   uint64
+	shards outputGroup
+}
+
+func (s *shardWriter) Write(p []byte) (int, error) {
+	i := (atomic.AddUint64(&s.next, 1) - 1) % uint64(len(s.shards))
+	return s.shards[i].Write(p)
+}
+
+func (s *shardWriter) unwrapAll() []io.Writer {
+	return s.shards
+}
+
+func (s *shardWriter) Flush() error {
+	return s.shards.Flush()
+}
+
+func (s *shardWriter) Close() error {
+	return s.shards.Close()
+}
+
+// expandForward turns a filter with a forward property into a two output
+// filter: its own output at the filter level plus the forward destination
+// at forwardlevel (the filter level by default). forward is one of
+//
+//	syslog                   the local syslog daemon
+//	syslog://host:514        a remote syslog daemon over udp
+//	syslog+tcp://host:514    the same over tcp
+//	tcp://host:port          a network collector; udp:// works too
+func expandForward(fc FilterConfig) (FilterConfig, error) {
+	forward := strings.TrimSpace(fc.Properties["forward"])
+	if forward == "" || len(fc.Outputs) > 0 {
+		return fc, nil
+	}
+	dest := OutputConfig{Level: fc.Properties["forwardlevel"], Properties: map[string]string{}}
+	scheme, address := forward, ""
+	if i := strings.Index(forward, "://"); i >= 0 {
+		scheme, address = forward[:i], forward[i+3:]
+	}
+	switch scheme {
+	case "syslog":
+		dest.Type = "syslog"
+		if address != "" {
+			dest.Properties["network"] = "udp"
+		}
+	case "syslog+tcp", "syslog+udp":
+		dest.Type = "syslog"
+		dest.Properties["network"] = strings.TrimPrefix(scheme, "syslog+")
+	case "tcp", "udp":
+		dest.Type = "network"
+		dest.Properties["network"] = scheme
+	default:
+		return fc, fmt.Errorf("forward %q: unknown destination", forward)
+	}
+	if dest.Type == "network" || dest.Properties["network"] != "" {
+		if address == "" {
+			return fc, fmt.Errorf("forward %q: missing address", forward)
+		}
+		dest.Properties["address"] = address
+	}
+	fc.Outputs = []OutputConfig{{Type: fc.Type, Level: fc.Level}, dest}
+	return fc, nil
+}
+
+// preflight checks that files can be created next to filename, since
+// rotatelogs would only find out on the first write
+func preflight(filename string) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); nil != err {
+		return fmt.Errorf("log path %s is not writable: %w", dir, err)
+	}
+	probe, err := ioutil.TempFile(dir, ".logx-preflight-")
+	if nil != err {
+		return fmt.Errorf("log path %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// levelWriter is implemented by outputs that need the level of each entry,
+// such as the Windows event log. They are fed by a levelHook rather than
+// through the logger output.
+type levelWriter interface {
+	WriteLevel(level logrus.Level, p []byte) (int, error)
+}
+
+// levelHook renders entries up to threshold and hands them to a levelWriter
+type levelHook struct {
+	w         levelWriter
+	threshold logrus.Level
+	formatter logrus.Formatter
+}
+
+func (h *levelHook) Levels() []logrus.Level {
+	return levelsUpTo(h.threshold)
+}
+
+// levelsUpTo lists the levels at or more severe than threshold
+func levelsUpTo(threshold logrus.Level) []logrus.Level {
+	var levels []logrus.Level
+	for _, lv := range logrus.AllLevels {
+		if lv <= threshold {
+			levels = append(levels, lv)
+		}
+	}
+	return levels
+}
+
+func (h *levelHook) Fire(e *logrus.Entry) error {
+	p, err := h.formatter.Format(e)
+	if nil != err || len(p) == 0 {
+		return err
+	}
+	_, err = h.w.WriteLevel(e.Level, p)
+	return err
+}
+
+// outputHook returns the hook feeding w for outputs that need more than the
+// rendered entry, levelWriter and entryWriter ones, and nil for the others
+func outputHook(w io.Writer, threshold logrus.Level, formatter logrus.Formatter) logrus.Hook {
+	switch t := w.(type) {
+	case levelWriter:
+		return &levelHook{w: t, threshold: threshold, formatter: formatter}
+	case entryWriter:
+		return &entryHook{w: t, threshold: threshold, formatter: formatter}
+	}
+	return nil
+}
+
+// discardOutput stops l from rendering and writing on its own, for loggers
+// whose destinations are all fed by hooks
+func discardOutput(l *logrus.Logger) {
+	l.SetOutput(io.Discard)
+	l.SetFormatter(discardFormatter{})
+}
+
+// attachOutputs routes the entries of l to every output of fc through one
+// lfshook WriterMap each, so every output gets its own level threshold. The
+// logger itself writes nowhere; its level is lowered to the most verbose
+// output. The returned group flushes and closes the opened writers.
+func attachOutputs(l *logrus.Logger, logPath string, fc FilterConfig, level logrus.Level) (io.Writer, error) {
+	var group outputGroup
+	for _, oc := range fc.Outputs {
+		threshold := level
+		if oc.Level != "" {
+			var err error
+			if threshold, err = logrus.ParseLevel(oc.Level); nil != err {
+				return nil, fmt.Errorf("%s: %w", fc.Tag, err)
+			}
+		}
+		props := make(map[string]string, len(fc.Properties)+len(oc.Properties))
+		for k, v := range fc.Properties {
+			props[k] = v
+		}
+		for k, v := range oc.Properties {
+			props[k] = v
+		}
+
+		w, err := buildOutput(l, logPath, fc.Tag, oc.Type, props)
+		if nil != err {
+			return nil, err
+		}
+		if w != io.Writer(os.Stdout) {
+			group = append(group, w)
+		}
+
+		inner, err := filterFormatter(props)
+		if nil != err {
+			return nil, fmt.Errorf("%s: %w", fc.Tag, err)
+		}
+		formatter := newEntryFormatter(fc.Tag, inner)
+		if ef, ok := l.Formatter.(*entryFormatter); ok {
+			formatter.seq = ef.seq
+		}
+		if err := configureSampling(formatter, props); nil != err {
+			return nil, fmt.Errorf("%s: %w", fc.Tag, err)
+		}
+		configureFields(formatter, props)
+		configureAuto(formatter, w)
+		formatter.deliver = deliverTo(w)
+		formatter.dedup.emit = formatter.emit
+		if h := outputHook(w, threshold, formatter); nil != h {
+			l.AddHook(h)
+		} else {
+			writers := lfshook.WriterMap{}
+			for _, lv := range logrus.AllLevels {
+				if lv <= threshold {
+					writers[lv] = w
+				}
+			}
+			l.AddHook(lfshook.NewHook(writers, formatter))
+		}
+		if threshold > l.GetLevel() {
+			l.SetLevel(threshold)
+		}
+	}
+	discardOutput(l)
+	return group, nil
+}
+
+// deliverTo writes rendered entries straight to w, the way its output
+// hook or the logger would
+func deliverTo(w io.Writer) func(*logrus.Entry, []byte) {
+	return func(e *logrus.Entry, p []byte) {
+		switch t := w.(type) {
+		case levelWriter:
+			t.WriteLevel(e.Level, p)
+		case entryWriter:
+			t.WriteEntry(e, p)
+		default:
+			w.Write(p)
+		}
+	}
+}
+
+// discardFormatter skips rendering for loggers whose output is io.Discard
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+// flushOutput flushes w when it buffers
+func flushOutput(w io.Writer) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// closeWriter closes w when it is a Closer
+func closeWriter(w io.Writer) error {
+	if c, ok := w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// wrapper is implemented by writers wrapping one output, such as buffers
+// and queues; unwrap returns the writer entries go on to
+type wrapper interface {
+	unwrap() io.Writer
+}
+
+// multiWrapper is implemented by writers spreading entries over several
+// outputs
+type multiWrapper interface {
+	unwrapAll() []io.Writer
+}
+
+// outputGroup is the set of writers opened for one multi-output logger
+type outputGroup []io.Writer
+
+func (g outputGroup) Write(p []byte) (int, error) {
+	for _, w := range g {
+		if _, err := w.Write(p); nil != err {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (g outputGroup) unwrapAll() []io.Writer {
+	return g
+}
+
+func (g outputGroup) Flush() error {
+	var first error
+	for _, w := range g {
+		if f, ok := w.(interface{ Flush() error }); ok {
+			if err := f.Flush(); nil != err && nil == first {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+func (g outputGroup) Close() error {
+	var first error
+	for _, w := range g {
+		if c, ok := w.(io.Closer); ok {
+			if err := c.Close(); nil != err && nil == first {
+				first = err
+			}
+		}
+	}
+	return first
+}
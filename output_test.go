@@ -0,0 +1,254 @@
+package logx
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMultipleOutputs(t *testing.T) {
+	defer Snapshot()()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	received := make(chan string, 10)
+	go func() {
+		conn, err := lis.Accept()
+		if nil != err {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			received <- scanner.Text()
+		}
+	}()
+
+	dir := t.TempDir()
+	console, err := os.Create(path.Join(dir, "console.out"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = console
+	defer func() { os.Stdout = stdout }()
+
+	err = InitLoggerFromFilters(dir, []FilterConfig{{Tag: "multi", Level: "info", Outputs: []OutputConfig{
+		{Type: "console", Level: "debug"},
+		{Type: "network", Level: "error", Properties: map[string]string{"address": lis.Addr().String()}},
+	}}})
+	os.Stdout = stdout
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	l := GetLogger("multi")
+	l.Debug("debug only on console")
+	l.Error("error everywhere")
+
+	select {
+	case line := <-received:
+		if !strings.Contains(line, "error everywhere") {
+			t.Errorf("network sink got %q", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("network sink got nothing")
+	}
+	select {
+	case line := <-received:
+		t.Errorf("network sink got a second line %q", line)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	content, _ := ioutil.ReadFile(console.Name())
+	if !strings.Contains(string(content), "debug only on console") || !strings.Contains(string(content), "error everywhere") {
+		t.Errorf("console got %q", content)
+	}
+}
+
+// stampHook sets a field on every entry it fires for
+type stampHook struct{}
+
+func (stampHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (stampHook) Fire(e *logrus.Entry) error {
+	e.Data["stamped"] = true
+	return nil
+}
+
+func TestHookAddedAfterInit(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	console, restore := redirectStdout(t)
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "late", Level: "info", Outputs: []OutputConfig{
+		{Type: "file"},
+		{Type: "console"},
+	}}})
+	restore()
+	if nil != err {
+		t.Fatal(err)
+	}
+	addHook(stampHook{})
+
+	GetLogger("late").Info("after the hook")
+	Flush()
+	for _, name := range []string{path.Join(dir, "late.log"), console.Name()} {
+		content, _ := ioutil.ReadFile(name)
+		if !strings.Contains(string(content), "stamped=true") {
+			t.Errorf("%s lacks the field of the late hook: %q", name, content)
+		}
+	}
+}
+
+// recordingOutput is an entryWriter output type for the tests
+type recordingOutput struct {
+	mu      sync.Mutex
+	tag     string
+	entries []*logrus.Entry
+	lines   []string
+	flushed bool
+	closed  bool
+}
+
+func (w *recordingOutput) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *recordingOutput) WriteEntry(e *logrus.Entry, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries = append(w.entries, e)
+	w.lines = append(w.lines, string(p))
+	return len(p), nil
+}
+
+func (w *recordingOutput) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.flushed = true
+	return nil
+}
+
+func (w *recordingOutput) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+// registerRecording registers typ as a recordingOutput type until the test
+// ends and returns the writers it opens
+func registerRecording(t *testing.T, typ string) *[]*recordingOutput {
+	return registerRecordingDescribed(t, typ, nil)
+}
+
+func registerRecordingDescribed(t *testing.T, typ string, describe func(tag string, props map[string]string) string) *[]*recordingOutput {
+	var opened []*recordingOutput
+	err := RegisterOutputType(typ, func(tag string, props map[string]string) (io.Writer, error) {
+		w := &recordingOutput{tag: tag}
+		opened = append(opened, w)
+		return w, nil
+	}, describe)
+	if nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		outputTypesLock.Lock()
+		delete(outputTypes, typ)
+		outputTypesLock.Unlock()
+	})
+	return &opened
+}
+
+func TestRegisterOutputType(t *testing.T) {
+	defer Snapshot()()
+	opened := registerRecording(t, "recording")
+	err := InitLoggerFromFilters(t.TempDir(), []FilterConfig{{Tag: "rec", Type: "recording", Level: "info"}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	GetLogger("rec").WithField("user", "ada").Info("hello")
+	GetLogger("rec").Debug("below the level")
+	Flush()
+
+	if len(*opened) != 1 || (*opened)[0].tag != "rec" {
+		t.Fatalf("opened %v", *opened)
+	}
+	w := (*opened)[0]
+	if len(w.entries) != 1 || w.entries[0].Message != "hello" || w.entries[0].Data["user"] != "ada" {
+		t.Errorf("entries %v", w.entries)
+	}
+	if !w.flushed {
+		t.Error("Flush did not reach the output")
+	}
+
+	if err := RegisterOutputType("file", nil, nil); nil == err {
+		t.Error("built in type replaced")
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	filters := []FilterConfig{{Tag: "boot", Type: "file", Level: "info"}}
+
+	// a file in the way of the log directory
+	blocked := path.Join(dir, "blocked")
+	ioutil.WriteFile(blocked, nil, 0644)
+	err := InitLoggerFromFilters(path.Join(blocked, "logs"), filters)
+	if nil == err || !strings.Contains(err.Error(), "boot") || !strings.Contains(err.Error(), blocked) {
+		t.Errorf("got %v, want an error naming the tag and path", err)
+	}
+
+	if os.Getuid() != 0 {
+		readonly := path.Join(dir, "readonly")
+		os.Mkdir(readonly, 0555)
+		if err := InitLoggerFromFilters(readonly, filters); nil == err {
+			t.Error("read-only log path accepted")
+		}
+	}
+
+	ok := path.Join(dir, "ok")
+	if err := InitLoggerFromFilters(ok, filters); nil != err {
+		t.Fatal(err)
+	}
+	if leftovers, _ := filepath.Glob(path.Join(ok, ".logx-preflight-*")); len(leftovers) != 0 {
+		t.Errorf("probe files left behind: %v", leftovers)
+	}
+}
+
+func TestShards(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "busy", Type: "file", Level: "info", Properties: map[string]string{"shards": "3"}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l := GetLogger("busy")
+	for i := 0; i < 9; i++ {
+		l.Infof("entry %d", i)
+	}
+	Flush()
+
+	for i := 0; i < 3; i++ {
+		content, _ := ioutil.ReadFile(path.Join(dir, "busy."+strconv.Itoa(i)+".log"))
+		if n := strings.Count(string(content), "entry"); n != 3 {
+			t.Errorf("shard %d got %d entries:\n%s", i, n, content)
+		}
+	}
+}
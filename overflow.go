@@ -0,0 +1,284 @@
+package logx
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultQueueSize = 1024
+
+// OverflowPolicy decides what a queued writer does with a write that finds
+// its queue full because the sink cannot keep up
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes the logging goroutine wait for room
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNew discards the write that does not fit
+	OverflowDropNew
+	// OverflowDropOld discards the oldest queued write to make room
+	OverflowDropOld
+)
+
+// dropped counts the writes discarded under each policy, expired those
+// discarded for outliving the ttl of their output and timedOut those that
+// waited for room longer than the writetimeout of their output
+var dropped [3]uint64
+var expired uint64
+var timedOut uint64
+
+// Dropped returns how many writes were discarded under p so far, across
+// all writers
+func (p OverflowPolicy) Dropped() uint64 {
+	return atomic.LoadUint64(&dropped[p])
+}
+
+// DroppedExpired returns how many queued writes were discarded so far for
+// being older than the ttl of their network output
+func DroppedExpired() uint64 {
+	return atomic.LoadUint64(&expired)
+}
+
+// DroppedTimeout returns how many writes were discarded so far for finding
+// the queue of their file output full for longer than its writetimeout
+func DroppedTimeout() uint64 {
+	return atomic.LoadUint64(&timedOut)
+}
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDropNew:
+		return "dropnew"
+	case OverflowDropOld:
+		return "dropold"
+	}
+	return "block"
+}
+
+func parseOverflow(v string) (OverflowPolicy, error) {
+	switch v {
+	case "block":
+		return OverflowBlock, nil
+	case "dropnew":
+		return OverflowDropNew, nil
+	case "dropold":
+		return OverflowDropOld, nil
+	}
+	return 0, fmt.Errorf("overflow %q is not block, dropnew or dropold", v)
+}
+
+// queuedWriter hands writes to a goroutine feeding out through a bounded
+// queue, applying policy when the queue is full
+type queuedWriter struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	out      io.Writer
+	policy   OverflowPolicy
+	size     int
+	ttl      time.Duration
+	timeout  time.Duration
+	stats    *tagStats
+	queue    []queuedWrite
+	inFlight bool
+	closed   bool
+	done     chan struct{}
+}
+
+// queuedWrite is one write waiting in a queuedWriter
+type queuedWrite struct {
+	p  []byte
+	at time.Time
+}
+
+func newQueuedWriter(out io.Writer, size int, policy OverflowPolicy) *queuedWriter {
+	w := &queuedWriter{out: out, policy: policy, size: size, done: make(chan struct{})}
+	w.cond = sync.NewCond(&w.mu)
+	go w.loop()
+	return w
+}
+
+// overflowOutput puts out behind a queue when the overflow property is
+// set; queuesize (default 1024) is the number of writes it holds
+func overflowOutput(out io.Writer, props map[string]string) (io.Writer, error) {
+	v, ok := props["overflow"]
+	if !ok {
+		return out, nil
+	}
+	policy, err := parseOverflow(v)
+	if nil != err {
+		return nil, err
+	}
+	size := strToNumSuffix(props["queuesize"], 1000)
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	return newQueuedWriter(out, size, policy), nil
+}
+
+// ttlOutput makes the queue of a network output discard the writes that
+// waited longer than the ttl property, such as 30s, instead of delivering
+// them late once a backlog clears. Without an overflow property the output
+// gets a blocking queue for it. File outputs ignore ttl.
+func ttlOutput(out io.Writer, props map[string]string) (io.Writer, error) {
+	v, ok := props["ttl"]
+	if !ok {
+		return out, nil
+	}
+	ttl, err := time.ParseDuration(strings.TrimSpace(v))
+	if nil != err || ttl <= 0 {
+		return nil, fmt.Errorf("ttl %q is not a positive duration", v)
+	}
+	q, ok := out.(*queuedWriter)
+	if !ok {
+		q = newQueuedWriter(out, defaultQueueSize, OverflowBlock)
+	}
+	q.ttl = ttl
+	return q, nil
+}
+
+// writeTimeoutOutput bounds how long a write to a file output may hold up
+// the logging goroutine to the writetimeout property, such as 100ms. Writes
+// go through a queue, queuesize long, that a background goroutine feeds to
+// the file; a write finding it full for longer than the timeout, because
+// the storage stalls, is dropped and counted by DroppedTimeout. Without an
+// overflow property the output gets a queue for it.
+func writeTimeoutOutput(out io.Writer, props map[string]string) (io.Writer, error) {
+	v, ok := props["writetimeout"]
+	if !ok {
+		return out, nil
+	}
+	timeout, err := time.ParseDuration(strings.TrimSpace(v))
+	if nil != err || timeout <= 0 {
+		return nil, fmt.Errorf("writetimeout %q is not a positive duration", v)
+	}
+	q, ok := out.(*queuedWriter)
+	if !ok {
+		size := strToNumSuffix(props["queuesize"], 1000)
+		if size <= 0 {
+			size = defaultQueueSize
+		}
+		q = newQueuedWriter(out, size, OverflowBlock)
+	}
+	q.timeout = timeout
+	return q, nil
+}
+
+func (w *queuedWriter) loop() {
+	defer close(w.done)
+	w.mu.Lock()
+	for {
+		for len(w.queue) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			return
+		}
+		next := w.queue[0]
+		w.queue = w.queue[1:]
+		if w.ttl > 0 && time.Since(next.at) > w.ttl {
+			atomic.AddUint64(&expired, 1)
+			w.stats.drop()
+			w.cond.Broadcast()
+			continue
+		}
+		w.inFlight = true
+		w.mu.Unlock()
+		w.out.Write(next.p)
+		w.mu.Lock()
+		w.inFlight = false
+		w.cond.Broadcast()
+	}
+}
+
+func (w *queuedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if len(w.queue) >= w.size {
+		switch w.policy {
+		case OverflowDropNew:
+			atomic.AddUint64(&dropped[OverflowDropNew], 1)
+			w.stats.drop()
+			return len(p), nil
+		case OverflowDropOld:
+			w.queue = w.queue[1:]
+			atomic.AddUint64(&dropped[OverflowDropOld], 1)
+			w.stats.drop()
+		default:
+			if !w.wait() {
+				atomic.AddUint64(&timedOut, 1)
+				w.stats.drop()
+				return len(p), nil
+			}
+			if w.closed {
+				return 0, io.ErrClosedPipe
+			}
+		}
+	}
+	// logrus reuses its buffers once Write returns
+	w.queue = append(w.queue, queuedWrite{p: append([]byte(nil), p...), at: time.Now()})
+	w.cond.Broadcast()
+	return len(p), nil
+}
+
+// wait blocks until the queue has room or is closed, giving up after the
+// timeout if any; callers hold mu
+func (w *queuedWriter) wait() bool {
+	if w.timeout <= 0 {
+		for len(w.queue) >= w.size && !w.closed {
+			w.cond.Wait()
+		}
+		return true
+	}
+	deadline := time.Now().Add(w.timeout)
+	wake := time.AfterFunc(w.timeout, func() {
+		w.mu.Lock()
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	})
+	defer wake.Stop()
+	for len(w.queue) >= w.size && !w.closed {
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		w.cond.Wait()
+	}
+	return true
+}
+
+func (w *queuedWriter) unwrap() io.Writer {
+	return w.out
+}
+
+// Flush waits for the queue to drain, then flushes out
+func (w *queuedWriter) Flush() error {
+	w.mu.Lock()
+	for len(w.queue) > 0 || w.inFlight {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+	if f, ok := w.out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close writes out what is queued and closes out
+func (w *queuedWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	<-w.done
+	if c, ok := w.out.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
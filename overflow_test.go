@@ -0,0 +1,174 @@
+package logx
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedWriter is a sink that holds every write until released
+type gatedWriter struct {
+	mu      sync.Mutex
+	got     []string
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{entered: make(chan struct{}, 100), release: make(chan struct{})}
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	g.entered <- struct{}{}
+	<-g.release
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.got = append(g.got, string(p))
+	return len(p), nil
+}
+
+func (g *gatedWriter) Got() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return strings.Join(g.got, ",")
+}
+
+// saturate leaves write 1 stuck in the sink and 2 and 3 queued
+func saturate(t *testing.T, policy OverflowPolicy) (*queuedWriter, *gatedWriter) {
+	g := newGatedWriter()
+	w := newQueuedWriter(g, 2, policy)
+	w.Write([]byte("1"))
+	select {
+	case <-g.entered:
+	case <-time.After(time.Second):
+		t.Fatal("first write never reached the sink")
+	}
+	w.Write([]byte("2"))
+	w.Write([]byte("3"))
+	return w, g
+}
+
+func TestOverflowDropNew(t *testing.T) {
+	before := OverflowDropNew.Dropped()
+	w, g := saturate(t, OverflowDropNew)
+	w.Write([]byte("4"))
+	close(g.release)
+	w.Close()
+	if got := g.Got(); got != "1,2,3" {
+		t.Errorf("sink got %s", got)
+	}
+	if n := OverflowDropNew.Dropped() - before; n != 1 {
+		t.Errorf("dropped %d, want 1", n)
+	}
+}
+
+func TestOverflowDropOld(t *testing.T) {
+	before := OverflowDropOld.Dropped()
+	w, g := saturate(t, OverflowDropOld)
+	w.Write([]byte("4"))
+	close(g.release)
+	w.Close()
+	if got := g.Got(); got != "1,3,4" {
+		t.Errorf("sink got %s", got)
+	}
+	if n := OverflowDropOld.Dropped() - before; n != 1 {
+		t.Errorf("dropped %d, want 1", n)
+	}
+}
+
+func TestOverflowBlock(t *testing.T) {
+	w, g := saturate(t, OverflowBlock)
+	returned := make(chan struct{})
+	go func() {
+		w.Write([]byte("4"))
+		close(returned)
+	}()
+	select {
+	case <-returned:
+		t.Fatal("write returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(g.release)
+	<-returned
+	w.Close()
+	if got := g.Got(); got != "1,2,3,4" {
+		t.Errorf("sink got %s", got)
+	}
+}
+
+func TestOverflowProperty(t *testing.T) {
+	out := &syncBuffer{}
+	if w, _ := overflowOutput(out, nil); w != out {
+		t.Error("writer queued without the overflow property")
+	}
+	if _, err := overflowOutput(out, map[string]string{"overflow": "spill"}); nil == err {
+		t.Error("unknown policy accepted")
+	}
+	w, err := overflowOutput(out, map[string]string{"overflow": "dropold", "queuesize": "10"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	qw := w.(*queuedWriter)
+	defer qw.Close()
+	if qw.size != 10 || qw.policy != OverflowDropOld {
+		t.Errorf("queue of %d under %s", qw.size, qw.policy)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	before := DroppedExpired()
+	g := newGatedWriter()
+	w, err := ttlOutput(g, map[string]string{"ttl": "50ms"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	w.Write([]byte("1"))
+	<-g.entered
+	w.Write([]byte("stale"))
+	time.Sleep(100 * time.Millisecond)
+	w.Write([]byte("fresh"))
+	close(g.release)
+	w.(*queuedWriter).Close()
+	if got := g.Got(); got != "1,fresh" {
+		t.Errorf("sink got %s", got)
+	}
+	if n := DroppedExpired() - before; n != 1 {
+		t.Errorf("expired %d writes, want 1", n)
+	}
+
+	if _, err := ttlOutput(g, map[string]string{"ttl": "soon"}); nil == err {
+		t.Error("bad ttl accepted")
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	before := DroppedTimeout()
+	g := newGatedWriter()
+	w, err := writeTimeoutOutput(g, map[string]string{"writetimeout": "50ms", "queuesize": "1"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	w.Write([]byte("1"))
+	<-g.entered
+	w.Write([]byte("2"))
+	start := time.Now()
+	if n, err := w.Write([]byte("late")); nil != err || n != 4 {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	if waited := time.Since(start); waited < 50*time.Millisecond || waited > time.Second {
+		t.Errorf("write waited %s on a stalled file, want about 50ms", waited)
+	}
+	close(g.release)
+	w.(*queuedWriter).Close()
+	if got := g.Got(); got != "1,2" {
+		t.Errorf("sink got %s", got)
+	}
+	if n := DroppedTimeout() - before; n != 1 {
+		t.Errorf("timed out %d writes, want 1", n)
+	}
+
+	if _, err := writeTimeoutOutput(g, map[string]string{"writetimeout": "-1s"}); nil == err {
+		t.Error("bad writetimeout accepted")
+	}
+}
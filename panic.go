@@ -0,0 +1,57 @@
+package logx
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/rifflock/lfshook"
+	"github.com/sirupsen/logrus"
+)
+
+// FatalOnPanic is meant for the top of main, as
+//
+//	defer logx.FatalOnPanic("stderr")()
+//
+// On panic it logs the value and the stack at Fatal level to the logger
+// named tag, flushes and closes all writers so the entry reaches disk, then
+// exits with status 2. The panic is not swallowed: the process always ends.
+func FatalOnPanic(tag string) func() {
+	return func() {
+		r := recover()
+		if nil == r {
+			return
+		}
+		logger := GetLogger(tag)
+		logger.WithFields(logrus.Fields{
+			"panic": fmt.Sprint(r),
+			"stack": string(debug.Stack()),
+		}).Log(logrus.FatalLevel, "panic: ", r)
+		Close()
+		logger.Exit(2)
+	}
+}
+
+// crashHook copies the Panic and Fatal entries of the logger it is added to
+// into the output of the crash logger, with a stack field when the entry
+// has none. InitLogger installs it on the standard logger and the other
+// loggers it builds whenever a "crash" tag is configured, so crash reports
+// live in their own file on their own retention, apart from stderr.
+func crashHook(crash *logrus.Logger) logrus.Hook {
+	return lfshook.NewHook(lfshook.WriterMap{
+		logrus.PanicLevel: crash.Out,
+		logrus.FatalLevel: crash.Out,
+	}, stackFormatter{crash.Formatter})
+}
+
+// stackFormatter adds the current stack to entries before inner renders them
+type stackFormatter struct {
+	inner logrus.Formatter
+}
+
+func (f stackFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	if _, ok := e.Data["stack"]; !ok {
+		e = cloneEntry(e)
+		e.Data["stack"] = string(debug.Stack())
+	}
+	return f.inner.Format(e)
+}
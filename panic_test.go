@@ -0,0 +1,67 @@
+package logx
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestFatalOnPanic(t *testing.T) {
+	if dir := os.Getenv("LOGX_PANIC_DIR"); dir != "" {
+		if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "panic-test", Type: "file", Level: "info"}}); nil != err {
+			t.Fatal(err)
+		}
+		defer FatalOnPanic("panic-test")()
+		panic("boom in child")
+	}
+
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFatalOnPanic$")
+	cmd.Env = append(os.Environ(), "LOGX_PANIC_DIR="+dir)
+	err := cmd.Run()
+	if exit, ok := err.(*exec.ExitError); !ok || exit.ExitCode() != 2 {
+		t.Fatalf("child should exit with status 2, got %v", err)
+	}
+
+	content, err := ioutil.ReadFile(path.Join(dir, "panic-test.log"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"FATAL", "boom in child", "goroutine", "TestFatalOnPanic"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("crash log is missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestCrashTag(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{
+		{Tag: "crash", Type: "file", Level: "error"},
+		{Tag: "app", Type: "file", Level: "info"},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	func() {
+		defer func() { recover() }()
+		GetLogger("app").Error("handled error")
+		GetLogger("app").Panic("boom")
+	}()
+
+	content, _ := ioutil.ReadFile(path.Join(dir, "crash.log"))
+	if !strings.Contains(string(content), "PANIC boom") || !strings.Contains(string(content), "stack=") {
+		t.Errorf("crash.log is missing the panic and its stack:\n%s", content)
+	}
+	if strings.Contains(string(content), "handled error") {
+		t.Errorf("crash.log got an Error entry:\n%s", content)
+	}
+	if app, _ := ioutil.ReadFile(path.Join(dir, "app.log")); strings.Contains(string(app), "stack=") {
+		t.Errorf("stack leaked into app.log:\n%s", app)
+	}
+}
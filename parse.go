@@ -0,0 +1,87 @@
+package logx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Entry is one line of a JSON log written by the json format
+type Entry struct {
+	Time    time.Time
+	Level   logrus.Level
+	Message string
+	Fields  map[string]interface{}
+}
+
+// ParseEntries reads newline delimited JSON logs, as written with
+// format=json, back into entries. Lines that are not such an entry are
+// skipped; the returned error then counts them and wraps the first
+// problem. Read errors stop parsing.
+func ParseEntries(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var bad, firstLine int
+	var first error
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			e, perr := parseEntry(line)
+			if nil == perr {
+				entries = append(entries, e)
+			} else {
+				if nil == first {
+					first, firstLine = perr, n
+				}
+				bad++
+			}
+		}
+		if io.EOF == err {
+			break
+		}
+		if nil != err {
+			return entries, err
+		}
+	}
+	if nil != first {
+		return entries, fmt.Errorf("%d malformed lines, first at line %d: %w", bad, firstLine, first)
+	}
+	return entries, nil
+}
+
+func parseEntry(line []byte) (Entry, error) {
+	var data map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(line))
+	d.UseNumber()
+	if err := d.Decode(&data); nil != err {
+		return Entry{}, err
+	}
+
+	e := Entry{Fields: data}
+	if v, ok := data[logrus.FieldKeyTime].(string); ok {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if nil != err {
+			return Entry{}, err
+		}
+		e.Time = t
+	}
+	level, ok := data[logrus.FieldKeyLevel].(string)
+	if !ok {
+		return Entry{}, fmt.Errorf("no %s key", logrus.FieldKeyLevel)
+	}
+	lv, err := logrus.ParseLevel(level)
+	if nil != err {
+		return Entry{}, err
+	}
+	e.Level = lv
+	e.Message, _ = data[logrus.FieldKeyMsg].(string)
+	delete(data, logrus.FieldKeyTime)
+	delete(data, logrus.FieldKeyLevel)
+	delete(data, logrus.FieldKeyMsg)
+	return e, nil
+}
@@ -0,0 +1,49 @@
+package logx
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseEntries(t *testing.T) {
+	f, _ := filterFormatter(map[string]string{"format": "json"})
+	now := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var logs bytes.Buffer
+	for _, e := range []*logrus.Entry{
+		{Time: now, Level: logrus.InfoLevel, Message: "first", Data: logrus.Fields{"user": "bob", "n": 3}},
+		{Time: now.Add(time.Second), Level: logrus.ErrorLevel, Message: "second", Data: logrus.Fields{}},
+	} {
+		out, err := f.Format(e)
+		if nil != err {
+			t.Fatal(err)
+		}
+		logs.Write(out)
+		if e.Level == logrus.InfoLevel {
+			logs.WriteString("not json\n")
+		}
+	}
+
+	entries, err := ParseEntries(&logs)
+	if nil == err || !strings.Contains(err.Error(), "1 malformed lines, first at line 2") {
+		t.Errorf("malformed line reported as %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("parsed %d entries", len(entries))
+	}
+	e := entries[0]
+	if !e.Time.Equal(now) || e.Level != logrus.InfoLevel || e.Message != "first" {
+		t.Errorf("parsed %+v", e)
+	}
+	if e.Fields["user"] != "bob" || e.Fields["n"] != json.Number("3") || len(e.Fields) != 2 {
+		t.Errorf("parsed fields %v", e.Fields)
+	}
+	if entries[1].Level != logrus.ErrorLevel || entries[1].Message != "second" {
+		t.Errorf("parsed %+v", entries[1])
+	}
+}
@@ -0,0 +1,169 @@
+package logx
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Transform rewrites an entry before it is formatted; returning nil drops
+// the entry.
+type Transform func(*logrus.Entry) *logrus.Entry
+
+var transformLock sync.RWMutex
+var transforms []Transform
+
+// AddTransform registers fn to run, after the transforms registered before
+// it, on every entry of every managed logger before the formatter sees it.
+// Transforms run on the logging goroutine for each entry and should be
+// cheap.
+func AddTransform(fn Transform) {
+	transformLock.Lock()
+	defer transformLock.Unlock()
+	transforms = append(transforms, fn)
+}
+
+// applyTransforms runs the transforms on a copy of e, so that an entry
+// rendered by several outputs is only transformed once per output
+func applyTransforms(e *logrus.Entry) *logrus.Entry {
+	transformLock.RLock()
+	defer transformLock.RUnlock()
+	if len(transforms) == 0 {
+		return e
+	}
+	e = cloneEntry(e)
+	for _, fn := range transforms {
+		if e = fn(e); nil == e {
+			return nil
+		}
+	}
+	return e
+}
+
+// cloneEntry copies e along with the parts Dup leaves out
+func cloneEntry(e *logrus.Entry) *logrus.Entry {
+	c := e.Dup()
+	c.Level, c.Message, c.Caller, c.Buffer = e.Level, e.Message, e.Caller, e.Buffer
+	return c
+}
+
+// entryFormatter is installed on every logger built by this package. It
+// gives package level features a place to rewrite or drop entries before
+// the configured formatter renders them; a dropped entry renders as nothing.
+type entryFormatter struct {
+	tag    string
+	inner  logrus.Formatter
+	dedup  dedupState
+	sample sampleState
+	limit  rateState
+	fields fieldFilter
+	stats  *tagStats
+	// seq counts the entries of the logger, shared by its outputs
+	seq *uint64
+	// deliver, when set, writes the summaries rendered ahead of an entry
+	// straight to the output, so that each is a record of its own
+	deliver func(e *logrus.Entry, p []byte)
+}
+
+func newEntryFormatter(tag string, inner logrus.Formatter) *entryFormatter {
+	return &entryFormatter{tag: tag, inner: inner, stats: statsFor(tag), seq: new(uint64)}
+}
+
+func (f *entryFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	orig := e
+	if gated(f.tag, e) {
+		return nil, nil
+	}
+	if e = applyTransforms(truncateEntry(withDefaultFields(e))); nil == e {
+		return nil, nil
+	}
+	e = f.fields.prune(foldLines(encodeBytes(withCorrelation(e))))
+	if f.sample.drop(e) {
+		f.stats.drop()
+		return nil, nil
+	}
+	limited, limitSummary := f.limit.allow(f.tag, e)
+	if limited {
+		f.stats.drop()
+		if nil == limitSummary {
+			return nil, nil
+		}
+		out, err := f.inner.Format(limitSummary)
+		if nil == err {
+			record(out)
+			f.stats.wrote(out)
+		}
+		return out, err
+	}
+	if e.HasCaller() {
+		e = withCaller(e, f.inner)
+	}
+	drop, summary := f.dedup.filter(e)
+	if drop {
+		return nil, nil
+	}
+	if atomic.LoadInt32(&seqEnabled) == 1 {
+		n := f.number(orig)
+		e = cloneEntry(e)
+		e.Data["seq"] = n
+	}
+	out, err := f.inner.Format(e)
+	if nil != err {
+		return out, err
+	}
+
+	if nil != summary {
+		summary.Time = e.Time
+	}
+	var prev []byte
+	for _, s := range []*logrus.Entry{limitSummary, summary} {
+		if nil != s {
+			prev = append(prev, f.ahead(s)...)
+		}
+	}
+	out = append(prev, out...)
+	record(out)
+	f.stats.wrote(out)
+	return out, nil
+}
+
+// number returns the seq of e, taken from the logger counter by the first
+// output rendering it and kept on e for the others. The outputs of one
+// entry render it one after the other on the logging goroutine.
+func (f *entryFormatter) number(e *logrus.Entry) uint64 {
+	if n, ok := e.Data["seq"].(seqNumber); ok {
+		return uint64(n)
+	}
+	n := atomic.AddUint64(f.seq, 1)
+	e.Data["seq"] = seqNumber(n)
+	return n
+}
+
+// seqNumber marks the seq number kept on an entry by its first output
+type seqNumber uint64
+
+// emit renders e and writes it through deliver, for entries written from
+// outside the logger such as the summary of an elapsed dedup window
+func (f *entryFormatter) emit(e *logrus.Entry) {
+	if p, err := f.Format(e); nil == err && len(p) > 0 {
+		f.deliver(e, p)
+	}
+}
+
+// ahead renders s, a summary that goes before the entry being formatted.
+// With deliver set it is written on its own and nothing is returned,
+// otherwise the caller prepends the rendered summary to the entry.
+func (f *entryFormatter) ahead(s *logrus.Entry) []byte {
+	p, err := f.inner.Format(s)
+	if nil != err {
+		return nil
+	}
+	if nil == f.deliver {
+		return p
+	}
+	record(p)
+	f.stats.wrote(p)
+	f.deliver(s, p)
+	return nil
+}
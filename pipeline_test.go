@@ -0,0 +1,59 @@
+package logx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// keepTransforms restores the registered transforms after a test
+func keepTransforms(t *testing.T) {
+	transformLock.RLock()
+	saved := append([]Transform(nil), transforms...)
+	transformLock.RUnlock()
+	t.Cleanup(func() {
+		transformLock.Lock()
+		transforms = saved
+		transformLock.Unlock()
+	})
+}
+
+func TestTransforms(t *testing.T) {
+	keepTransforms(t)
+	AddTransform(func(e *logrus.Entry) *logrus.Entry {
+		e.Message = strings.Replace(e.Message, "\n", " ", -1)
+		return e
+	})
+	AddTransform(func(e *logrus.Entry) *logrus.Entry {
+		data := make(logrus.Fields, len(e.Data))
+		for k, v := range e.Data {
+			data[strings.ToLower(k)] = v
+		}
+		e.Data = data
+		return e
+	})
+	AddTransform(func(e *logrus.Entry) *logrus.Entry {
+		e.Message += " [second]"
+		return e
+	})
+	AddTransform(func(e *logrus.Entry) *logrus.Entry {
+		if e.Message == "drop me [second]" {
+			return nil
+		}
+		return e
+	})
+
+	out := &syncBuffer{}
+	l := newTestLogger("transform", out)
+	l.WithField("UserID", 7).Info("line one\nline two")
+	l.Info("drop me")
+
+	lines := out.Lines()
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1: %q", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], `msg="line one line two [second]"`) || !strings.Contains(lines[0], "userid=7") {
+		t.Errorf("transforms not applied in order: %q", lines[0])
+	}
+}
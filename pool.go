@@ -0,0 +1,49 @@
+package logx
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var entryPool = sync.Pool{New: func() interface{} {
+	return &logrus.Entry{Data: make(logrus.Fields, 8)}
+}}
+
+// AcquireEntry returns a pooled entry of the logger named tag with no
+// fields, for hot paths where WithFields allocations show up in profiles.
+// Set fields directly in its Data map, log through it, then hand it back
+// with ReleaseEntry:
+//
+//	e := logx.AcquireEntry("api")
+//	e.Data["route"] = route
+//	e.Data["status"] = status
+//	e.Info("request")
+//	logx.ReleaseEntry(e)
+//
+// The entry, and its Data map, must not be used or kept after
+// ReleaseEntry, nor shared between goroutines. Deriving entries with
+// WithField and friends is fine but allocates like the plain API does.
+// Hooks and outputs are not affected: logrus logs a copy of the entry.
+func AcquireEntry(tag string) *logrus.Entry {
+	e := entryPool.Get().(*logrus.Entry)
+	e.Logger = GetLogger(tag)
+	return e
+}
+
+// ReleaseEntry clears an entry obtained from AcquireEntry and returns it to
+// the pool. Releasing any other entry, or the same entry twice, corrupts
+// the pool.
+func ReleaseEntry(e *logrus.Entry) {
+	for k := range e.Data {
+		delete(e.Data, k)
+	}
+	e.Logger = nil
+	e.Time = time.Time{}
+	e.Context = nil
+	e.Message = ""
+	e.Caller = nil
+	e.Buffer = nil
+	entryPool.Put(e)
+}
@@ -0,0 +1,62 @@
+package logx
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestAcquireEntry(t *testing.T) {
+	defer Snapshot()()
+	out := &syncBuffer{}
+	loggers["pooled"] = newTestLogger("pooled", out)
+
+	e := AcquireEntry("pooled")
+	e.Data["route"] = "/users"
+	e.Info("first")
+	ReleaseEntry(e)
+
+	e = AcquireEntry("pooled")
+	if len(e.Data) != 0 {
+		t.Errorf("reacquired entry kept fields %v", e.Data)
+	}
+	e.Data["status"] = 200
+	e.Info("second")
+	ReleaseEntry(e)
+
+	lines := out.Lines()
+	if !strings.Contains(lines[0], "route=/users") || strings.Contains(lines[1], "route") || !strings.Contains(lines[1], "status=200") {
+		t.Errorf("lines %q", lines)
+	}
+}
+
+func benchmarkLogger(b *testing.B) func() {
+	restore := Snapshot()
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetFormatter(newEntryFormatter("bench", &logrus.TextFormatter{DisableTimestamp: true}))
+	loggers["bench"] = l
+	b.ReportAllocs()
+	b.ResetTimer()
+	return restore
+}
+
+func BenchmarkWithFields(b *testing.B) {
+	defer benchmarkLogger(b)()
+	for i := 0; i < b.N; i++ {
+		GetLogger("bench").WithFields(logrus.Fields{"route": "/users", "status": 200}).Info("request")
+	}
+}
+
+func BenchmarkAcquireEntry(b *testing.B) {
+	defer benchmarkLogger(b)()
+	for i := 0; i < b.N; i++ {
+		e := AcquireEntry("bench")
+		e.Data["route"] = "/users"
+		e.Data["status"] = 200
+		e.Info("request")
+		ReleaseEntry(e)
+	}
+}
@@ -0,0 +1,133 @@
+package logx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// maxFrameSize bounds the length DecodeFrame accepts
+const maxFrameSize = 64 << 20
+
+// LogRecord is one entry as written by the "proto" format; see
+// logrecord.proto for the wire schema.
+type LogRecord struct {
+	Time    time.Time
+	Level   string
+	Message string
+	Fields  map[string]string
+}
+
+// protoFormatter writes each entry as a length-prefixed LogRecord
+type protoFormatter struct{}
+
+func (f *protoFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b := make([]byte, 4, 128)
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(entry.Time.UnixNano()))
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendString(b, entry.Level.String())
+	b = protowire.AppendTag(b, 3, protowire.BytesType)
+	b = protowire.AppendString(b, entry.Message)
+	for k, v := range entry.Data {
+		var kv []byte
+		kv = protowire.AppendTag(kv, 1, protowire.BytesType)
+		kv = protowire.AppendString(kv, k)
+		kv = protowire.AppendTag(kv, 2, protowire.BytesType)
+		kv = protowire.AppendString(kv, fieldString(v))
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendBytes(b, kv)
+	}
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+	return b, nil
+}
+
+func fieldString(v interface{}) string {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+	return fmt.Sprint(v)
+}
+
+// DecodeFrame reads one length-prefixed LogRecord written by the "proto"
+// format. It returns io.EOF when r is exhausted between frames.
+func DecodeFrame(r io.Reader) (*LogRecord, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); nil != err {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxFrameSize {
+		return nil, fmt.Errorf("logx: frame of %d bytes exceeds limit", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); nil != err {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	rec := &LogRecord{Fields: make(map[string]string)}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(b)
+			rec.Time = time.Unix(0, int64(v))
+		case num == 2 && typ == protowire.BytesType:
+			rec.Level, n = protowire.ConsumeString(b)
+		case num == 3 && typ == protowire.BytesType:
+			rec.Message, n = protowire.ConsumeString(b)
+		case num == 4 && typ == protowire.BytesType:
+			var kv []byte
+			if kv, n = protowire.ConsumeBytes(b); n >= 0 {
+				k, v, err := decodeField(kv)
+				if nil != err {
+					return nil, err
+				}
+				rec.Fields[k] = v
+			}
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return rec, nil
+}
+
+// decodeField decodes one entry of the fields map
+func decodeField(b []byte) (key, value string, err error) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return "", "", protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			key, n = protowire.ConsumeString(b)
+		case num == 2 && typ == protowire.BytesType:
+			value, n = protowire.ConsumeString(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return "", "", protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return key, value, nil
+}
@@ -0,0 +1,49 @@
+package logx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestProtoFrameRoundTrip(t *testing.T) {
+	f, _ := filterFormatter(map[string]string{"format": "proto"})
+	now := time.Date(2023, 1, 1, 10, 0, 0, 123, time.UTC)
+
+	var stream bytes.Buffer
+	for _, e := range []*logrus.Entry{
+		{Time: now, Level: logrus.InfoLevel, Message: "first", Data: logrus.Fields{"user": "bob", "n": 3}},
+		{Time: now.Add(time.Second), Level: logrus.ErrorLevel, Message: "second", Data: logrus.Fields{logrus.ErrorKey: errors.New("boom")}},
+	} {
+		out, err := f.Format(e)
+		if nil != err {
+			t.Fatal(err)
+		}
+		stream.Write(out)
+	}
+
+	rec, err := DecodeFrame(&stream)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !rec.Time.Equal(now) || rec.Level != "info" || rec.Message != "first" ||
+		rec.Fields["user"] != "bob" || rec.Fields["n"] != "3" {
+		t.Errorf("first frame decoded as %+v", rec)
+	}
+
+	rec, err = DecodeFrame(&stream)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if rec.Level != "error" || rec.Message != "second" || rec.Fields[logrus.ErrorKey] != "boom" {
+		t.Errorf("second frame decoded as %+v", rec)
+	}
+
+	if _, err := DecodeFrame(&stream); err != io.EOF {
+		t.Errorf("expected io.EOF after the last frame, got %v", err)
+	}
+}
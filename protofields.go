@@ -0,0 +1,88 @@
+package logx
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProtoFields extracts the fields at the dotted paths of m, such as
+// "user.id", into Fields keyed by path, for
+//
+//	logger.WithFields(logx.ProtoFields(req, "user.id", "action"))
+//
+// Path elements are proto field names (JSON names work too). Unknown
+// paths, paths through unset messages and unset optional fields are
+// skipped. Enums render by name, messages as JSON and repeated fields as
+// slices.
+func ProtoFields(m proto.Message, paths ...string) logrus.Fields {
+	fields := make(logrus.Fields, len(paths))
+	if nil == m {
+		return fields
+	}
+	for _, path := range paths {
+		if v, ok := protoPath(m.ProtoReflect(), strings.Split(path, ".")); ok {
+			fields[path] = v
+		}
+	}
+	return fields
+}
+
+func protoPath(m protoreflect.Message, names []string) (interface{}, bool) {
+	if !m.IsValid() {
+		return nil, false
+	}
+	fds := m.Descriptor().Fields()
+	fd := fds.ByName(protoreflect.Name(names[0]))
+	if nil == fd {
+		fd = fds.ByJSONName(names[0])
+	}
+	if nil == fd || (fd.HasPresence() && !m.Has(fd)) {
+		return nil, false
+	}
+	v := m.Get(fd)
+	if len(names) > 1 {
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+			return nil, false
+		}
+		return protoPath(v.Message(), names[1:])
+	}
+	switch {
+	case fd.IsList():
+		list := v.List()
+		values := make([]interface{}, list.Len())
+		for i := range values {
+			values[i] = protoValue(fd, list.Get(i))
+		}
+		return values, true
+	case fd.IsMap():
+		entries := make(map[string]interface{}, v.Map().Len())
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			entries[k.String()] = protoValue(fd.MapValue(), mv)
+			return true
+		})
+		return entries, true
+	}
+	return protoValue(fd, v), true
+}
+
+// protoValue turns a singular value of fd into a plain Go value
+func protoValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); nil != ev {
+			return string(ev.Name())
+		}
+		return int32(v.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		b, err := protojson.Marshal(v.Message().Interface())
+		if nil != err {
+			return err.Error()
+		}
+		return string(b)
+	}
+	return v.Interface()
+}
@@ -0,0 +1,33 @@
+package logx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestProtoFields(t *testing.T) {
+	m := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("user.proto"),
+		Dependency: []string{"a.proto", "b.proto"},
+		Options: &descriptorpb.FileOptions{
+			JavaPackage: proto.String("com.example"),
+			OptimizeFor: descriptorpb.FileOptions_SPEED.Enum(),
+		},
+	}
+	got := ProtoFields(m, "name", "options.java_package", "options.optimize_for", "options.javaPackage",
+		"dependency", "options.go_package", "source_code_info.location", "no_such.path", "name.deeper")
+	want := logrus.Fields{
+		"name":                 "user.proto",
+		"options.java_package": "com.example",
+		"options.optimize_for": "SPEED",
+		"options.javaPackage":  "com.example",
+		"dependency":           []interface{}{"a.proto", "b.proto"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProtoFields() = %v, want %v", got, want)
+	}
+}
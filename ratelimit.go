@@ -0,0 +1,84 @@
+package logx
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// rateSummaryInterval is how often a rate limited logger reports its drops
+const rateSummaryInterval = time.Second
+
+// rateLimits holds the map[string]float64 of lines per second by tag set by
+// SetRateLimit
+var rateLimits atomic.Value
+
+// SetRateLimit caps the loggers named in perTag at that many lines per
+// second, with bursts of up to a second worth of lines, to protect the disk
+// from runaway logging. Entries over the rate are dropped and, at most once
+// a second, a Warn "rate limit exceeded, dropped N" entry reports how many.
+// Panic and Fatal entries are never dropped. It replaces the previous
+// limits; tags left out, or with a rate of 0, are not limited.
+func SetRateLimit(perTag map[string]float64) {
+	limits := make(map[string]float64, len(perTag))
+	for tag, rate := range perTag {
+		if rate > 0 {
+			limits[tag] = rate
+		}
+	}
+	rateLimits.Store(limits)
+}
+
+// rateState is the token bucket of one logger
+type rateState struct {
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time
+	dropped int
+	since   time.Time
+}
+
+// allow reports whether e is over the rate of tag and should be dropped,
+// along with the summary of the drops when one is due
+func (r *rateState) allow(tag string, e *logrus.Entry) (drop bool, summary *logrus.Entry) {
+	limits, _ := rateLimits.Load().(map[string]float64)
+	rate, ok := limits[tag]
+	if !ok || e.Level <= logrus.FatalLevel {
+		return false, nil
+	}
+	burst := rate
+	if burst < 1 {
+		burst = 1
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last.IsZero() || e.Time.Before(r.last) {
+		r.tokens, r.last = burst, e.Time
+	}
+	r.tokens += e.Time.Sub(r.last).Seconds() * rate
+	if r.tokens > burst {
+		r.tokens = burst
+	}
+	r.last = e.Time
+	if r.tokens >= 1 {
+		r.tokens--
+	} else {
+		if r.dropped == 0 {
+			r.since = e.Time
+		}
+		r.dropped++
+		drop = true
+	}
+
+	if r.dropped > 0 && e.Time.Sub(r.since) >= rateSummaryInterval {
+		summary = logrus.NewEntry(e.Logger)
+		summary.Time, summary.Level = e.Time, logrus.WarnLevel
+		summary.Message = fmt.Sprintf("rate limit exceeded, dropped %d", r.dropped)
+		r.dropped = 0
+	}
+	return drop, summary
+}
@@ -0,0 +1,48 @@
+package logx
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSetRateLimit(t *testing.T) {
+	SetRateLimit(map[string]float64{"chatty": 10})
+	defer SetRateLimit(nil)
+
+	out := &syncBuffer{}
+	l := newTestLogger("chatty", out)
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	// 100 lines a second for three seconds
+	for i := 0; i < 300; i++ {
+		l.WithTime(start.Add(time.Duration(i) * 10 * time.Millisecond)).Info("spew")
+	}
+
+	var written, summaries int
+	for _, line := range out.Lines() {
+		switch {
+		case strings.Contains(line, "rate limit exceeded, dropped"):
+			summaries++
+		case strings.Contains(line, "spew"):
+			written++
+		}
+	}
+	// a burst of 10, then 10 a second
+	if written < 35 || written > 45 {
+		t.Errorf("%d of 300 lines written at 10/s", written)
+	}
+	if summaries < 2 || summaries > 3 {
+		t.Errorf("%d summaries in 3s, want one a second", summaries)
+	}
+
+	other := &syncBuffer{}
+	u := newTestLogger("quiet", other)
+	for i := 0; i < 50; i++ {
+		u.WithTime(start).Log(logrus.InfoLevel, "unlimited")
+	}
+	if n := len(other.Lines()); n != 50 {
+		t.Errorf("unlimited tag wrote %d of 50", n)
+	}
+}
@@ -0,0 +1,68 @@
+package logx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// recordWriter ends every record with sep instead of the newline the
+// formatters write
+type recordWriter struct {
+	out io.Writer
+	sep []byte
+}
+
+// recordOutput applies the recordsep property, written with Go escapes
+// such as \x00 or \r\n; \0 is accepted for NUL. Without it out is returned
+// unchanged.
+func recordOutput(out io.Writer, props map[string]string) (io.Writer, error) {
+	v, ok := props["recordsep"]
+	if !ok || v == `\n` {
+		return out, nil
+	}
+	sep := "\x00"
+	if v != `\0` {
+		var err error
+		if sep, err = strconv.Unquote(`"` + v + `"`); nil != err || sep == "" {
+			return nil, fmt.Errorf("recordsep %q is not a valid separator", v)
+		}
+	}
+	return &recordWriter{out: out, sep: []byte(sep)}, nil
+}
+
+// Write takes one formatted record per call, as logrus writes them; the
+// entry formatter writes the summaries it renders ahead of an entry as
+// records of their own
+func (w *recordWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		// an entry the formatter dropped
+		return 0, nil
+	}
+	rec := make([]byte, 0, len(p)+len(w.sep))
+	rec = append(rec, bytes.TrimSuffix(p, []byte("\n"))...)
+	rec = append(rec, w.sep...)
+	if _, err := w.out.Write(rec); nil != err {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *recordWriter) unwrap() io.Writer {
+	return w.out
+}
+
+func (w *recordWriter) Flush() error {
+	if f, ok := w.out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (w *recordWriter) Close() error {
+	if c, ok := w.out.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
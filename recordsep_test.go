@@ -0,0 +1,71 @@
+package logx
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordSeparator(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "nul", Type: "file", Level: "info",
+		Properties: map[string]string{"recordsep": `\0`, "format": "json"}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l := GetLogger("nul")
+	l.Info("first")
+	l.Info("second\nwith a newline")
+
+	content, _ := ioutil.ReadFile(path.Join(dir, "nul.log"))
+	records := strings.Split(string(content), "\x00")
+	if len(records) != 3 || records[2] != "" {
+		t.Fatalf("records %q", records)
+	}
+	for _, r := range records[:2] {
+		if strings.HasSuffix(r, "\n") {
+			t.Errorf("record %q kept the formatter newline", r)
+		}
+	}
+
+	if _, err := recordOutput(nil, map[string]string{"recordsep": `\q`}); nil == err {
+		t.Error("bad escape accepted")
+	}
+	if w, _ := recordOutput(nil, map[string]string{"recordsep": `\r\n`}); string(w.(*recordWriter).sep) != "\r\n" {
+		t.Error("escapes not decoded")
+	}
+}
+
+func TestRecordSeparatorSummaries(t *testing.T) {
+	defer Snapshot()()
+	EnableDedup(time.Hour)
+	defer EnableDedup(0)
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "nul", Type: "file", Level: "info",
+		Properties: map[string]string{"recordsep": `\0`, "format": "json"}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l := GetLogger("nul")
+	l.Error("boom")
+	l.Error("boom")
+	l.Error("boom")
+	l.Info("other")
+
+	content, _ := ioutil.ReadFile(path.Join(dir, "nul.log"))
+	records := strings.Split(string(content), "\x00")
+	if len(records) != 4 || records[3] != "" {
+		t.Fatalf("records %q", records)
+	}
+	if !strings.Contains(records[1], "boom [repeated 2 times]") {
+		t.Errorf("summary record %q", records[1])
+	}
+	for _, r := range records[:3] {
+		if strings.Contains(r, "\n") {
+			t.Errorf("record %q holds a newline", r)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package logx
+
+import (
+	"io"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// redirectLock guards redirected, the loggers RedirectOutput took over
+var redirectLock sync.Mutex
+var redirected = make(map[*logrus.Logger]*redirection)
+
+// redirection is what RedirectOutput took from a logger, restored once its
+// last redirect is undone, and the redirects in effect, the last one on top
+type redirection struct {
+	out       io.Writer
+	formatter logrus.Formatter
+	outputs   logrus.LevelHooks
+	stack     []*redirect
+}
+
+type redirect struct {
+	w io.Writer
+}
+
+// RedirectOutput sends the entries of the logger GetLogger resolves for
+// tag to w instead of its outputs until restore is called. Loggers whose
+// outputs are fed by hooks, such as those with several outputs or split
+// levels, stop writing to them as well and render to w as text; the other
+// hooks keep running. Redirects of one logger stack: restore undoes its
+// own, and the logger goes back to the last redirect still in effect or to
+// its outputs. See logxtest.RedirectToTestLog for tests.
+func RedirectOutput(tag string, w io.Writer) (restore func()) {
+	l := GetLogger(tag)
+	redirectLock.Lock()
+	defer redirectLock.Unlock()
+	r, ok := redirected[l]
+	if !ok {
+		r = &redirection{out: l.Out, formatter: l.Formatter, outputs: make(logrus.LevelHooks)}
+		lock.RLock()
+		kept := make(logrus.LevelHooks, len(l.Hooks))
+		for level, list := range l.Hooks {
+			for _, h := range list {
+				if isOutputHook(h) {
+					r.outputs[level] = append(r.outputs[level], h)
+				} else {
+					kept[level] = append(kept[level], h)
+				}
+			}
+		}
+		l.ReplaceHooks(kept)
+		lock.RUnlock()
+		if _, discard := l.Formatter.(discardFormatter); discard {
+			l.SetFormatter(newEntryFormatter(tag, txtFormatter))
+		}
+		redirected[l] = r
+	}
+	own := &redirect{w: w}
+	r.stack = append(r.stack, own)
+	l.SetOutput(w)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			redirectLock.Lock()
+			defer redirectLock.Unlock()
+			for i, rd := range r.stack {
+				if rd == own {
+					r.stack = append(r.stack[:i], r.stack[i+1:]...)
+					break
+				}
+			}
+			if len(r.stack) > 0 {
+				l.SetOutput(r.stack[len(r.stack)-1].w)
+				return
+			}
+			delete(redirected, l)
+			l.SetOutput(r.out)
+			l.SetFormatter(r.formatter)
+			// hooks added meanwhile stay, ahead of the outputs
+			lock.RLock()
+			hooks := make(logrus.LevelHooks, len(l.Hooks))
+			for level, list := range l.Hooks {
+				hooks[level] = append(hooks[level], list...)
+			}
+			for level, list := range r.outputs {
+				hooks[level] = append(hooks[level], list...)
+			}
+			l.ReplaceHooks(hooks)
+			lock.RUnlock()
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package logx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedirectOutput(t *testing.T) {
+	defer Snapshot()()
+	opened := registerRecording(t, "recording")
+	err := InitLoggerFromFilters(t.TempDir(), []FilterConfig{{Tag: "multi", Level: "info", Outputs: []OutputConfig{
+		{Type: "recording"},
+		{Type: "recording"},
+	}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l := GetLogger("multi")
+
+	outer, inner := &syncBuffer{}, &syncBuffer{}
+	restoreOuter := RedirectOutput("multi", outer)
+	l.Info("to outer")
+	restoreInner := RedirectOutput("multi", inner)
+	l.Info("to inner")
+	restoreInner()
+	l.Info("outer again")
+	restoreOuter()
+	l.Info("to the outputs")
+
+	if lines := outer.Lines(); len(lines) != 2 || !strings.Contains(lines[0], "to outer") || !strings.Contains(lines[1], "outer again") {
+		t.Errorf("outer redirect got %q", lines)
+	}
+	if lines := inner.Lines(); len(lines) != 1 || !strings.Contains(lines[0], "to inner") {
+		t.Errorf("inner redirect got %q", lines)
+	}
+	for _, w := range *opened {
+		if len(w.lines) != 1 || !strings.Contains(w.lines[0], "to the outputs") {
+			t.Errorf("output got %q", w.lines)
+		}
+	}
+
+	// undone out of order, the logger stays on the redirect in effect
+	restoreOuter = RedirectOutput("multi", outer)
+	restoreInner = RedirectOutput("multi", inner)
+	restoreOuter()
+	l.Info("still inner")
+	restoreInner()
+	if lines := inner.Lines(); len(lines) != 2 {
+		t.Errorf("inner redirect got %q", lines)
+	}
+}
@@ -0,0 +1,95 @@
+package logx
+
+import (
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Registry is a set of loggers configured apart from the package ones, for
+// libraries embedding logx that must leave the global logrus state of their
+// host alone. See InitLoggerIsolated.
+type Registry struct {
+	mu      sync.RWMutex
+	loggers map[string]*logrus.Logger
+	outputs map[string]io.Writer
+}
+
+// InitLoggerIsolated loads the XML configuration in filename like
+// InitLogger, but into a new Registry: GetLogger does not see its loggers
+// and no hook is added to the logrus standard logger. The default stdout
+// and stderr loggers are created within the registry, and additive filters
+// route to them.
+func InitLoggerIsolated(logPath, filename string) (*Registry, error) {
+	filters, err := readXMLConfig(filename)
+	if nil != err {
+		return nil, err
+	}
+	return newRegistry(logPath, filters)
+}
+
+func newRegistry(logPath string, filters []FilterConfig) (*Registry, error) {
+	lock.RLock()
+	built, writers, err := buildLoggers(logPath, filters)
+	withDefaults := createDefaults
+	lock.RUnlock()
+	r := &Registry{loggers: built, outputs: writers}
+	if nil != err {
+		r.Close()
+		return nil, err
+	}
+
+	for _, tag := range []string{"stderr", "stdout"} {
+		if _, ok := built[tag]; ok || !withDefaults {
+			continue
+		}
+		level := logrus.InfoLevel
+		if tag == "stderr" {
+			level = logrus.ErrorLevel
+		}
+		l, file := defaultFileLogger(logPath, tag, level)
+		r.loggers[tag] = l
+		r.outputs[tag] = file
+	}
+	for _, fc := range filters {
+		if additive, _ := strconv.ParseBool(fc.Properties["additive"]); !additive || fc.Tag == "stdout" || fc.Tag == "stderr" {
+			continue
+		}
+		l := r.loggers[fc.Tag]
+		for _, h := range defaultHooks(fc.Tag, l, fc.Properties, r.loggers["stderr"], r.loggers["stdout"]) {
+			l.AddHook(guard(fc.Tag, h))
+		}
+	}
+	return r, nil
+}
+
+// Get returns the logger of the registry configured for name, falling back
+// to the registry stdout logger and then to a console logger.
+func (r *Registry) Get(name string) *logrus.Logger {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if l, ok := r.loggers[name]; ok {
+		return l
+	}
+	if l, ok := r.loggers["stdout"]; ok {
+		return l
+	}
+	return consoleLogger()
+}
+
+// Close flushes and closes the writers of the registry. Its loggers must not
+// be used afterwards.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var first error
+	for tag, w := range r.outputs {
+		if err := closeOutput(tag, w); nil != err && nil == first {
+			first = err
+		}
+		delete(r.outputs, tag)
+	}
+	return first
+}
@@ -0,0 +1,59 @@
+package logx
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestInitLoggerIsolated(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "log.xml")
+	err := ioutil.WriteFile(conf, []byte(`<logging>
+  <filter enabled="true">
+    <tag>embedded</tag>
+    <type>file</type>
+    <level>INFO</level>
+  </filter>
+</logging>`), 0644)
+	if nil != err {
+		t.Fatal(err)
+	}
+	std := logrus.StandardLogger()
+	before := copyHooks(std.Hooks)
+	out, level := std.Out, std.GetLevel()
+
+	r, err := InitLoggerIsolated(dir, conf)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for lv, hooks := range std.Hooks {
+		if len(hooks) != len(before[lv]) {
+			t.Errorf("%s hooks of the standard logger changed: %d, want %d", lv, len(hooks), len(before[lv]))
+		}
+	}
+	if std.Out != out || std.GetLevel() != level {
+		t.Error("standard logger output or level changed")
+	}
+	if _, ok := GetLoggerByPrefix("embedded"); ok {
+		t.Error("registry logger visible through the package")
+	}
+
+	r.Get("embedded").Info("inside the registry")
+	if r.Get("unknown") != r.Get("stdout") {
+		t.Error("unknown name does not fall back to the registry stdout")
+	}
+	if err := r.Close(); nil != err {
+		t.Fatal(err)
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "embedded.log"))
+	if nil != err || !strings.Contains(string(contents), "inside the registry") {
+		t.Errorf("embedded.log = %q, %v", contents, err)
+	}
+}
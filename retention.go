@@ -0,0 +1,90 @@
+package logx
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
+)
+
+// retention keeps the newest files of one rotating writer, and those
+// modified within maxAge, and removes the rest after every rotation. File
+// ages are wall clock times, as the modification times are, whatever clock
+// drives the rotation. A zero keep or maxAge is no limit. rotatelogs' own
+// rotation count orders files by glob order, which puts size generations
+// such as ".10" before ".2", so it is disabled in favour of this.
+type retention struct {
+	mu     sync.Mutex
+	prefix string
+	keep   int
+	maxAge time.Duration
+}
+
+type rotatedFile struct {
+	path       string
+	stamp      string
+	generation int
+	modTime    time.Time
+}
+
+func (r *retention) Handle(ev rotatelogs.Event) {
+	if e, ok := ev.(*rotatelogs.FileRotatedEvent); ok {
+		r.purge(e.CurrentFile())
+	}
+}
+
+func (r *retention) purge(current string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matches, err := filepath.Glob(r.prefix + "*")
+	if nil != err {
+		return
+	}
+	files := make([]rotatedFile, 0, len(matches))
+	for _, m := range matches {
+		if strings.HasSuffix(m, "_lock") || strings.HasSuffix(m, "_symlink") {
+			continue
+		}
+		fi, err := os.Lstat(m)
+		if nil != err || fi.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		f := rotatedFile{path: m, stamp: strings.TrimPrefix(m, r.prefix), modTime: fi.ModTime()}
+		if i := strings.LastIndexByte(f.stamp, '.'); i >= 0 {
+			if g, err := strconv.Atoi(f.stamp[i+1:]); nil == err {
+				f.stamp, f.generation = f.stamp[:i], g
+			}
+		}
+		files = append(files, f)
+	}
+	if len(files) < 2 {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].stamp != files[j].stamp {
+			return files[i].stamp < files[j].stamp
+		}
+		return files[i].generation < files[j].generation
+	})
+	// the newest file is never removed, even when idle for longer than maxAge
+	remove := 0
+	if r.keep > 0 && len(files) > r.keep {
+		remove = len(files) - r.keep
+	}
+	expired := time.Now().Add(-r.maxAge)
+	for i, f := range files[:len(files)-1] {
+		if f.path == current {
+			continue
+		}
+		if i < remove || (r.maxAge > 0 && f.modTime.Before(expired)) {
+			os.Remove(f.path)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package logx
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ring holds the *ringBuffer installed by EnableRingBuffer
+var ring atomic.Value
+
+// ringBuffer keeps the last len(lines) formatted lines
+type ringBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+// EnableRingBuffer keeps the most recent size formatted lines written by
+// any managed logger in memory, for RecentLines. A size of 0 disables it
+// and drops what was kept.
+func EnableRingBuffer(size int) {
+	if size <= 0 {
+		ring.Store((*ringBuffer)(nil))
+		return
+	}
+	ring.Store(&ringBuffer{lines: make([]string, size)})
+}
+
+// RecentLines returns the lines kept by EnableRingBuffer, oldest first.
+func RecentLines() []string {
+	r, _ := ring.Load().(*ringBuffer)
+	if nil == r {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]string(nil), r.lines[:r.next]...)
+	}
+	return append(append([]string(nil), r.lines[r.next:]...), r.lines[:r.next]...)
+}
+
+// record stores the rendered output of one entry
+func record(out []byte) {
+	r, _ := ring.Load().(*ringBuffer)
+	if nil == r || len(out) == 0 {
+		return
+	}
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, line := range lines {
+		r.lines[r.next] = line
+		r.next++
+		if r.next == len(r.lines) {
+			r.next = 0
+			r.full = true
+		}
+	}
+}
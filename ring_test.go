@@ -0,0 +1,36 @@
+package logx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRingBuffer(t *testing.T) {
+	EnableRingBuffer(3)
+	defer EnableRingBuffer(0)
+
+	l := newTestLogger("ring", &syncBuffer{})
+	l.Info("line 0")
+	if got := RecentLines(); len(got) != 1 || !strings.Contains(got[0], "line 0") {
+		t.Fatalf("before wraparound got %q", got)
+	}
+
+	for i := 1; i < 5; i++ {
+		l.Info(fmt.Sprintf("line %d", i))
+	}
+	got := RecentLines()
+	if len(got) != 3 {
+		t.Fatalf("got %d lines, want 3: %q", len(got), got)
+	}
+	for i, want := range []string{"line 2", "line 3", "line 4"} {
+		if !strings.Contains(got[i], want) {
+			t.Errorf("line %d is %q, want %q", i, got[i], want)
+		}
+	}
+
+	EnableRingBuffer(0)
+	if got := RecentLines(); nil != got {
+		t.Errorf("disabled buffer returned %q", got)
+	}
+}
@@ -0,0 +1,88 @@
+package logx
+
+import (
+	"fmt"
+	"io"
+
+	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
+)
+
+// rotatingFiles digs the rotating files out of the wrappers of an output,
+// in the order of its members
+func rotatingFiles(w io.Writer) []*rotatelogs.RotateLogs {
+	switch t := w.(type) {
+	case *rotatelogs.RotateLogs:
+		return []*rotatelogs.RotateLogs{t}
+	case *fileWriter:
+		return []*rotatelogs.RotateLogs{t.RotateLogs}
+	case wrapper:
+		return rotatingFiles(t.unwrap())
+	case multiWrapper:
+		var files []*rotatelogs.RotateLogs
+		for _, member := range t.unwrapAll() {
+			files = append(files, rotatingFiles(member)...)
+		}
+		return files
+	}
+	return nil
+}
+
+// rotatingFile is the first of the rotating files of an output, nil when
+// it has none
+func rotatingFile(w io.Writer) *rotatelogs.RotateLogs {
+	if files := rotatingFiles(w); len(files) > 0 {
+		return files[0]
+	}
+	return nil
+}
+
+// Rotate makes the file output of the logger named tag switch to a new
+// file right away and returns the path of the file it left, ready to be
+// archived. Buffered lines are flushed into that file first. A sharded
+// output rotates every shard and returns the file the first one left. It
+// fails for unknown tags and for loggers without a file output.
+func Rotate(tag string) (closedFile string, err error) {
+	lock.RLock()
+	_, known := loggers[tag]
+	w, ok := outputs[tag]
+	lock.RUnlock()
+	if !known {
+		return "", fmt.Errorf("rotate: unknown tag %s", tag)
+	}
+	files := rotatingFiles(w)
+	if !ok || len(files) == 0 {
+		return "", fmt.Errorf("rotate: %s has no file output", tag)
+	}
+
+	if f, ok := w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); nil != err {
+			return "", fmt.Errorf("rotate %s: %w", tag, err)
+		}
+	}
+	closedFile = files[0].CurrentFileName()
+	for _, rl := range files {
+		if err := rl.Rotate(); nil != err {
+			return "", fmt.Errorf("rotate %s: %w", tag, err)
+		}
+	}
+	return closedFile, nil
+}
+
+// OpenFiles returns, for every tag with a file output, the path of the
+// rotated file it is writing, rather than the tag.log link to it. Tags
+// whose file is not open yet, because nothing was written, are left out, as
+// are outputs writing several files such as splitlevels and filetemplate
+// ones; sharded outputs report the file of their first shard.
+func OpenFiles() map[string]string {
+	lock.RLock()
+	defer lock.RUnlock()
+	files := make(map[string]string, len(outputs))
+	for tag, w := range outputs {
+		if rl := rotatingFile(w); nil != rl {
+			if name := rl.CurrentFileName(); name != "" {
+				files[tag] = name
+			}
+		}
+	}
+	return files
+}
@@ -0,0 +1,113 @@
+package logx
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotate(t *testing.T) {
+	defer Snapshot()()
+	fc := &fakeClock{now: time.Date(2023, 1, 1, 10, 0, 0, 0, time.Local)}
+	SetClock(fc)
+	defer SetClock(nil)
+
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{
+		{Tag: "run", Type: "file", Level: "info", Properties: map[string]string{"bufsize": "4K"}},
+		{Tag: "screen", Type: "console", Level: "info"},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l := GetLogger("run")
+	l.Info("test run one")
+	closed, err := Rotate("run")
+	if nil != err {
+		t.Fatal(err)
+	}
+	l.Info("test run two")
+	Flush()
+
+	if closed != path.Join(dir, "run.log-2023010110") {
+		t.Errorf("closed %s", closed)
+	}
+	if content, _ := ioutil.ReadFile(closed); !strings.Contains(string(content), "test run one") || strings.Contains(string(content), "two") {
+		t.Errorf("closed file holds %q", content)
+	}
+	if content, _ := ioutil.ReadFile(closed + ".1"); !strings.Contains(string(content), "test run two") {
+		t.Errorf("new file holds %q", content)
+	}
+
+	if _, err := Rotate("nobody"); nil == err {
+		t.Error("unknown tag rotated")
+	}
+	if _, err := Rotate("screen"); nil == err || !strings.Contains(err.Error(), "no file output") {
+		t.Errorf("console output rotated: %v", err)
+	}
+}
+
+func TestOpenFiles(t *testing.T) {
+	defer Snapshot()()
+	fc := &fakeClock{now: time.Date(2023, 1, 1, 10, 0, 0, 0, time.Local)}
+	SetClock(fc)
+	defer SetClock(nil)
+
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{
+		{Tag: "app", Type: "file", Level: "info"},
+		{Tag: "idle", Type: "file", Level: "info"},
+		{Tag: "screen", Type: "console", Level: "info"},
+		{Tag: "busy", Type: "file", Level: "info", Properties: map[string]string{"shards": "2"}},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer Close()
+	GetLogger("app").Info("held open")
+	GetLogger("busy").Info("shard zero")
+	GetLogger("busy").Info("shard one")
+
+	files := OpenFiles()
+	name, ok := files["app"]
+	if !ok || name != path.Join(dir, "app.log-2023010110") {
+		t.Fatalf("app is writing %q", name)
+	}
+	if fi, err := os.Lstat(name); nil != err || fi.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("%s is not the real file: %v", name, err)
+	}
+	if content, _ := ioutil.ReadFile(name); !strings.Contains(string(content), "held open") {
+		t.Errorf("%s holds %q", name, content)
+	}
+	for _, tag := range []string{"idle", "screen"} {
+		if _, ok := files[tag]; ok {
+			t.Errorf("%s reported with an open file", tag)
+		}
+	}
+
+	if _, err := Rotate("app"); nil != err {
+		t.Fatal(err)
+	}
+	if name := OpenFiles()["app"]; name != path.Join(dir, "app.log-2023010110.1") {
+		t.Errorf("after rotation app is writing %q", name)
+	}
+
+	// every shard rotates, the first one is reported
+	if name := files["busy"]; name != path.Join(dir, "busy.0.log-2023010110") {
+		t.Errorf("busy is writing %q", name)
+	}
+	closed, err := Rotate("busy")
+	if nil != err || closed != path.Join(dir, "busy.0.log-2023010110") {
+		t.Fatalf("rotated busy: %q, %v", closed, err)
+	}
+	GetLogger("busy").Info("after zero")
+	GetLogger("busy").Info("after one")
+	for _, name := range []string{"busy.0.log-2023010110.1", "busy.1.log-2023010110.1"} {
+		if content, _ := ioutil.ReadFile(path.Join(dir, name)); !strings.Contains(string(content), "after") {
+			t.Errorf("%s holds %q", name, content)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package logx
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
+)
+
+// archiveWorkers uploads run at once across all outputs, archiveQueueSize
+// rotated files wait for them
+const archiveWorkers = 4
+const archiveQueueSize = 64
+
+// archiveAttempts bounds the uploads of one file; the wait between them
+// starts at archiveBackoff and doubles
+var archiveAttempts = 5
+var archiveBackoff = time.Second
+
+// archiveUploads and archiveFailures count the files archived and those
+// given up on, after every attempt failed or with the queue full
+var archiveUploads uint64
+var archiveFailures uint64
+
+// ArchiveUploads returns how many rotated files were uploaded to S3 so far
+func ArchiveUploads() uint64 {
+	return atomic.LoadUint64(&archiveUploads)
+}
+
+// ArchiveFailures returns how many rotated files could not be uploaded to
+// S3 so far, after retries or because the uploads were too far behind
+func ArchiveFailures() uint64 {
+	return atomic.LoadUint64(&archiveFailures)
+}
+
+// ObjectStore is where s3archive outputs upload the rotated files; size is
+// the length of body, which PutObject may rewind to retry
+type ObjectStore interface {
+	PutObject(bucket string, key string, body io.ReadSeeker, size int64) error
+}
+
+var storeLock sync.Mutex
+var openObjectStore func(props map[string]string) (ObjectStore, error)
+
+// SetObjectStore sets how s3archive outputs get their ObjectStore from the
+// properties of their filter. Call it before InitLogger, which fails for
+// s3archive outputs until it is set. The AWS SDK client of the s3archive
+// module is the usual choice:
+//
+//	logx.SetObjectStore(s3archive.Open)
+func SetObjectStore(open func(props map[string]string) (ObjectStore, error)) {
+	storeLock.Lock()
+	defer storeLock.Unlock()
+	openObjectStore = open
+}
+
+// rotationHandlers runs several rotatelogs handlers, in order, for the one
+// a RotateLogs takes
+type rotationHandlers []rotatelogs.Handler
+
+func (hs rotationHandlers) Handle(ev rotatelogs.Event) {
+	for _, h := range hs {
+		h.Handle(ev)
+	}
+}
+
+// s3Archive reads the S3 archiving properties of a file output. With
+// s3archive set, every rotated file is gzipped and uploaded to bucket, with
+// the ObjectStore of SetObjectStore, as s3prefix + its name + ".gz", then
+// removed when s3deletelocal is set. The other properties, such as the
+// region and credentials, are the ObjectStore's. It returns nil when
+// s3archive is off.
+func s3Archive(props map[string]string) (rotatelogs.Handler, error) {
+	if on, _ := strconv.ParseBool(props["s3archive"]); !on {
+		return nil, nil
+	}
+	bucket := strings.TrimSpace(props["bucket"])
+	if bucket == "" {
+		return nil, errors.New("s3archive needs a bucket")
+	}
+	storeLock.Lock()
+	open := openObjectStore
+	storeLock.Unlock()
+	if nil == open {
+		return nil, errors.New("s3archive needs an object store, see SetObjectStore")
+	}
+	store, err := open(props)
+	if nil != err {
+		return nil, fmt.Errorf("s3archive: %w", err)
+	}
+	deleteLocal, _ := strconv.ParseBool(props["s3deletelocal"])
+	return &archiver{
+		store:       store,
+		bucket:      bucket,
+		prefix:      strings.TrimPrefix(strings.TrimSpace(props["s3prefix"]), "/"),
+		deleteLocal: deleteLocal,
+	}, nil
+}
+
+// archiver queues the files left by rotations for upload
+type archiver struct {
+	store       ObjectStore
+	bucket      string
+	prefix      string
+	deleteLocal bool
+}
+
+// archiveJob is one rotated file waiting for upload; the file is opened
+// when queued so retention removing it meanwhile does not lose it
+type archiveJob struct {
+	a    *archiver
+	name string
+	f    *os.File
+}
+
+var archiveQueue chan archiveJob
+var archiveOnce sync.Once
+
+func (a *archiver) Handle(ev rotatelogs.Event) {
+	e, ok := ev.(*rotatelogs.FileRotatedEvent)
+	if !ok || e.PreviousFile() == "" {
+		return
+	}
+	f, err := os.Open(e.PreviousFile())
+	if nil != err {
+		atomic.AddUint64(&archiveFailures, 1)
+		fmt.Fprintf(os.Stderr, "logx: s3archive %s: %v\n", e.PreviousFile(), err)
+		return
+	}
+	archiveOnce.Do(func() {
+		archiveQueue = make(chan archiveJob, archiveQueueSize)
+		for i := 0; i < archiveWorkers; i++ {
+			go func() {
+				for job := range archiveQueue {
+					job.run()
+				}
+			}()
+		}
+	})
+	// a file the uploads cannot keep up with is given up on rather than
+	// piling up blocked handlers, each holding a file open
+	select {
+	case archiveQueue <- archiveJob{a: a, name: e.PreviousFile(), f: f}:
+	default:
+		f.Close()
+		atomic.AddUint64(&archiveFailures, 1)
+		fmt.Fprintf(os.Stderr, "logx: s3archive %s: %d uploads already queued, not archived\n", e.PreviousFile(), archiveQueueSize)
+	}
+}
+
+func (job archiveJob) run() {
+	err := job.upload()
+	if nil != err {
+		atomic.AddUint64(&archiveFailures, 1)
+		fmt.Fprintf(os.Stderr, "logx: s3archive %s: %v\n", job.name, err)
+		return
+	}
+	atomic.AddUint64(&archiveUploads, 1)
+	if job.a.deleteLocal {
+		os.Remove(job.name)
+	}
+}
+
+// upload gzips the file to a temporary file, out of the log path, and puts
+// it, retrying with backoff
+func (job archiveJob) upload() error {
+	gz, err := ioutil.TempFile("", "logx-archive-")
+	if nil != err {
+		job.f.Close()
+		return err
+	}
+	defer os.Remove(gz.Name())
+	defer gz.Close()
+
+	zw := gzip.NewWriter(gz)
+	_, err = io.Copy(zw, job.f)
+	job.f.Close()
+	if nil == err {
+		err = zw.Close()
+	}
+	if nil != err {
+		return fmt.Errorf("gzip: %w", err)
+	}
+	size, err := gz.Seek(0, io.SeekCurrent)
+	if nil != err {
+		return err
+	}
+
+	key := job.a.prefix + filepath.Base(job.name) + ".gz"
+	wait := archiveBackoff
+	for attempt := 1; ; attempt++ {
+		if _, err = gz.Seek(0, io.SeekStart); nil != err {
+			return err
+		}
+		if err = job.a.store.PutObject(job.a.bucket, key, gz, size); nil == err {
+			return nil
+		}
+		if attempt >= archiveAttempts {
+			return fmt.Errorf("upload to s3://%s/%s failed %d times: %w", job.a.bucket, key, attempt, err)
+		}
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
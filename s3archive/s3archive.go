@@ -0,0 +1,75 @@
+// Package s3archive is the AWS SDK ObjectStore of logx s3archive outputs,
+// kept apart so that only programs archiving to S3 depend on the SDK:
+//
+//	logx.SetObjectStore(s3archive.Open)
+package s3archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/blackbeans/logx"
+)
+
+// Open builds the S3 client of an s3archive output from its properties.
+// s3region, s3endpoint for S3 compatible stores and the s3accesskey,
+// s3secretkey and s3sessiontoken credentials are optional; the SDK finds
+// the others as usual, from the AWS_ environment variables, the shared
+// configuration files and the ECS task or EC2 instance role.
+func Open(props map[string]string) (logx.ObjectStore, error) {
+	var opts []func(*config.LoadOptions) error
+	if region := strings.TrimSpace(props["s3region"]); region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	access, secret := strings.TrimSpace(props["s3accesskey"]), strings.TrimSpace(props["s3secretkey"])
+	if (access == "") != (secret == "") {
+		return nil, errors.New("s3accesskey and s3secretkey go together")
+	}
+	if access != "" {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(access, secret, strings.TrimSpace(props["s3sessiontoken"]))))
+	}
+	cfg, err := config.LoadDefaultConfig(context.Background(), opts...)
+	if nil != err {
+		return nil, fmt.Errorf("aws configuration: %w", err)
+	}
+	endpoint := strings.TrimSuffix(strings.TrimSpace(props["s3endpoint"]), "/")
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			// S3 compatible stores rarely serve bucket subdomains
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return &store{client: client}, nil
+}
+
+// putter is the call of the S3 client uploads need
+type putter interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+type store struct {
+	client putter
+}
+
+func (s *store) PutObject(bucket string, key string, body io.ReadSeeker, size int64) error {
+	_, err := s.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket:        aws.String(bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String("application/gzip"),
+	})
+	if nil != err {
+		return fmt.Errorf("s3 put %s: %w", key, err)
+	}
+	return nil
+}
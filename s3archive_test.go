@@ -0,0 +1,132 @@
+package logx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore records uploads, failing the first failures of them
+type fakeStore struct {
+	mu       sync.Mutex
+	failures int
+	objects  map[string][]byte
+	done     chan string
+}
+
+func (s *fakeStore) PutObject(bucket string, key string, body io.ReadSeeker, size int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("service unavailable")
+	}
+	b, err := ioutil.ReadAll(body)
+	if nil != err || int64(len(b)) != size {
+		return errors.New("short body")
+	}
+	s.objects[bucket+"/"+key] = b
+	s.done <- key
+	return nil
+}
+
+func TestS3Archive(t *testing.T) {
+	store := &fakeStore{failures: 1, objects: map[string][]byte{}, done: make(chan string, 1)}
+	SetObjectStore(func(map[string]string) (ObjectStore, error) { return store, nil })
+	defer SetObjectStore(nil)
+	archiveBackoff = time.Millisecond
+	defer func() { archiveBackoff = time.Second }()
+
+	dir := t.TempDir()
+	w, err := fileLogWriter(path.Join(dir, "app.log"), map[string]string{
+		"s3archive": "true", "bucket": "logs", "s3prefix": "prod/", "s3deletelocal": "true",
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer w.(io.Closer).Close()
+	rl := rotatingFile(w)
+	w.Write([]byte("before rotation\n"))
+	rotated := rl.CurrentFileName()
+	if err := rl.Rotate(); nil != err {
+		t.Fatal(err)
+	}
+
+	var key string
+	select {
+	case key = <-store.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("rotated file not uploaded")
+	}
+	if want := "prod/" + path.Base(rotated) + ".gz"; key != want {
+		t.Errorf("uploaded as %s, want %s", key, want)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(store.objects["logs/"+key]))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadAll(zr); string(b) != "before rotation\n" {
+		t.Errorf("archive holds %q", b)
+	}
+	deadline := time.Now().Add(time.Second)
+	for _, err := os.Stat(rotated); nil == err && time.Now().Before(deadline); _, err = os.Stat(rotated) {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := os.Stat(rotated); !os.IsNotExist(err) {
+		t.Errorf("local copy kept after upload: %v", err)
+	}
+
+	if _, err := fileLogWriter(path.Join(dir, "nobucket.log"), map[string]string{"s3archive": "true"}); nil == err {
+		t.Error("s3archive without a bucket accepted")
+	}
+	SetObjectStore(nil)
+	if _, err := fileLogWriter(path.Join(dir, "nostore.log"), map[string]string{"s3archive": "true", "bucket": "logs"}); nil == err {
+		t.Error("s3archive without an object store accepted")
+	}
+}
+
+// stuckStore holds every upload until released
+type stuckStore struct{ release chan struct{} }
+
+func (s stuckStore) PutObject(string, string, io.ReadSeeker, int64) error {
+	<-s.release
+	return nil
+}
+
+func TestS3ArchiveQueueFull(t *testing.T) {
+	store := stuckStore{release: make(chan struct{})}
+	defer close(store.release)
+	SetObjectStore(func(map[string]string) (ObjectStore, error) { return store, nil })
+	defer SetObjectStore(nil)
+
+	w, err := fileLogWriter(path.Join(t.TempDir(), "app.log"), map[string]string{"s3archive": "true", "bucket": "logs", "maxbackups": "0"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer w.(io.Closer).Close()
+	rl := rotatingFile(w)
+	failures := ArchiveFailures()
+	// at most the workers and the queue take files, rotations go on
+	const rotations = archiveWorkers + archiveQueueSize + 32
+	for i := 0; i < rotations; i++ {
+		w.Write([]byte("line\n"))
+		if err := rl.Rotate(); nil != err {
+			t.Fatal(err)
+		}
+	}
+	// rotatelogs runs the handlers on goroutines of their own
+	deadline := time.Now().Add(5 * time.Second)
+	for ArchiveFailures()-failures < 32 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := ArchiveFailures() - failures; n < 32 {
+		t.Errorf("%d files given up on, want at least 32", n)
+	}
+}
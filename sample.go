@@ -0,0 +1,138 @@
+package logx
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sampling parameters, read on every entry; samplingTick is 0 when
+// sampling is disabled
+var samplingTick, samplingFirst, samplingThereafter int64
+
+// samplingNeverBelow is the least severe level that is never sampled
+var samplingNeverBelow = uint32(logrus.ErrorLevel)
+
+// EnableSampling caps repeated messages: within each tick, of the entries
+// sharing a level and message the first are all written, then only one in
+// thereafter (none when thereafter is 0). Entries at neverBelow and more
+// severe levels are always written; pass logrus.ErrorLevel to keep every
+// error. A zero tick disables sampling.
+func EnableSampling(tick time.Duration, first, thereafter int, neverBelow logrus.Level) {
+	atomic.StoreInt64(&samplingFirst, int64(first))
+	atomic.StoreInt64(&samplingThereafter, int64(thereafter))
+	atomic.StoreUint32(&samplingNeverBelow, uint32(neverBelow))
+	atomic.StoreInt64(&samplingTick, int64(tick))
+}
+
+// SetSamplingRate changes how many repeats past the first ones make one
+// written entry, taking effect on the next entry of every logger.
+func SetSamplingRate(thereafter int) {
+	atomic.StoreInt64(&samplingThereafter, int64(thereafter))
+}
+
+// SamplingHandler reports the sampling parameters on GET and sets the rate
+// from the thereafter form value on POST or PUT, e.g.
+//
+//	curl -X PUT 'localhost:8080/debug/sampling?thereafter=100'
+func SamplingHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut:
+			n, err := strconv.Atoi(r.FormValue("thereafter"))
+			if nil != err || n < 0 {
+				http.Error(w, "thereafter must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			SetSamplingRate(n)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprintf(w, "tick=%s first=%d thereafter=%d neverbelow=%s\n",
+			time.Duration(atomic.LoadInt64(&samplingTick)),
+			atomic.LoadInt64(&samplingFirst),
+			atomic.LoadInt64(&samplingThereafter),
+			logrus.Level(atomic.LoadUint32(&samplingNeverBelow)))
+	})
+}
+
+// sampleState counts the messages of one logger in the current tick. off
+// and the first and thereafter overrides come from the sampling property
+// of its filter.
+type sampleState struct {
+	off        bool
+	override   bool
+	first      int64
+	thereafter int64
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[sampleKey]int64
+}
+
+type sampleKey struct {
+	level logrus.Level
+	msg   string
+}
+
+// drop reports whether e is sampled away
+func (s *sampleState) drop(e *logrus.Entry) bool {
+	tick := time.Duration(atomic.LoadInt64(&samplingTick))
+	if tick <= 0 || s.off || e.Level <= logrus.Level(atomic.LoadUint32(&samplingNeverBelow)) {
+		return false
+	}
+	first := atomic.LoadInt64(&samplingFirst)
+	thereafter := atomic.LoadInt64(&samplingThereafter)
+	if s.override {
+		first, thereafter = s.first, s.thereafter
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if nil == s.counts || e.Time.Sub(s.start) >= tick || e.Time.Before(s.start) {
+		s.start = e.Time
+		s.counts = make(map[sampleKey]int64)
+	}
+	key := sampleKey{e.Level, e.Message}
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= first {
+		return false
+	}
+	return thereafter <= 0 || (n-first)%thereafter != 0
+}
+
+// configureSampling applies the sampling property of a filter to the
+// entryFormatter f: "off" exempts the logger from sampling, "first,thereafter"
+// such as "10,100" replaces the global counts for it (the tick and the
+// never sampled levels stay global) and no property inherits EnableSampling.
+func configureSampling(f logrus.Formatter, props map[string]string) error {
+	v, ok := props["sampling"]
+	ef, managed := f.(*entryFormatter)
+	if !ok || !managed {
+		return nil
+	}
+	v = strings.TrimSpace(v)
+	if v == "off" {
+		ef.sample.off = true
+		return nil
+	}
+	parts := strings.Split(v, ",")
+	if len(parts) == 2 {
+		first, ferr := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+		thereafter, terr := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if nil == ferr && nil == terr && first >= 0 && thereafter >= 0 {
+			ef.sample.override, ef.sample.first, ef.sample.thereafter = true, first, thereafter
+			return nil
+		}
+	}
+	return fmt.Errorf("sampling %q is neither off nor first,thereafter", v)
+}
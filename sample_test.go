@@ -0,0 +1,142 @@
+package logx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSetSamplingRate(t *testing.T) {
+	EnableSampling(time.Hour, 2, 5, logrus.ErrorLevel)
+	defer EnableSampling(0, 0, 0, logrus.ErrorLevel)
+
+	out := &syncBuffer{}
+	l := newTestLogger("sample", out)
+	for i := 0; i < 12; i++ {
+		l.Info("busy")
+	}
+	// the first 2, then the 5th and 10th repeat past them
+	if n := len(out.Lines()); n != 4 {
+		t.Fatalf("%d lines at 1 in 5, want 4", n)
+	}
+
+	srv := httptest.NewServer(SamplingHandler())
+	defer srv.Close()
+	resp, err := http.Post(srv.URL+"?thereafter=2", "", nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	for i := 0; i < 10; i++ {
+		l.Info("busy")
+	}
+	if n := len(out.Lines()); n != 9 {
+		t.Fatalf("%d lines after switching to 1 in 2, want 9", n)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			SetSamplingRate(i%3 + 1)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			l.Info("concurrent")
+		}
+	}()
+	wg.Wait()
+
+	resp, err = http.Post(srv.URL+"?thereafter=-1", "", nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("negative rate answered %s", resp.Status)
+	}
+	rec := httptest.NewRecorder()
+	SamplingHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(rec.Body.String(), "first=2") {
+		t.Errorf("GET answered %q", rec.Body.String())
+	}
+}
+
+func TestNeverSampleLevel(t *testing.T) {
+	EnableSampling(time.Hour, 1, 0, logrus.ErrorLevel)
+	defer EnableSampling(0, 0, 0, logrus.ErrorLevel)
+
+	out := &syncBuffer{}
+	l := newTestLogger("exempt", out)
+	for i := 0; i < 5; i++ {
+		l.Info("chatty")
+		l.Error("failing")
+	}
+	var infos, errs int
+	for _, line := range out.Lines() {
+		if strings.Contains(line, "level=info") {
+			infos++
+		} else if strings.Contains(line, "level=error") {
+			errs++
+		}
+	}
+	if infos != 1 || errs != 5 {
+		t.Errorf("%d info and %d error lines, want 1 and 5", infos, errs)
+	}
+
+	// lowering the exemption to Fatal samples errors too
+	EnableSampling(time.Hour, 1, 0, logrus.FatalLevel)
+	l2 := newTestLogger("exempt2", out)
+	for i := 0; i < 3; i++ {
+		l2.Error("sampled now")
+	}
+	n := 0
+	for _, line := range out.Lines() {
+		if strings.Contains(line, "sampled now") {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("%d sampled error lines, want 1", n)
+	}
+}
+
+func TestSamplingProperty(t *testing.T) {
+	defer Snapshot()()
+	EnableSampling(time.Hour, 1, 0, logrus.ErrorLevel)
+	defer EnableSampling(0, 0, 0, logrus.ErrorLevel)
+
+	built, _, err := BuildLoggers(t.TempDir(), []FilterConfig{
+		{Tag: "audit", Type: "console", Level: "info", Properties: map[string]string{"sampling": "off"}},
+		{Tag: "busy", Type: "console", Level: "info"},
+		{Tag: "custom", Type: "console", Level: "info", Properties: map[string]string{"sampling": "2,0"}},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	for tag, want := range map[string]int{"audit": 5, "busy": 1, "custom": 2} {
+		out := &syncBuffer{}
+		l := built[tag]
+		l.SetOutput(out)
+		for i := 0; i < 5; i++ {
+			l.Info("login")
+		}
+		if n := len(out.Lines()); n != want {
+			t.Errorf("%s wrote %d of 5 entries, want %d", tag, n, want)
+		}
+	}
+
+	_, _, err = BuildLoggers(t.TempDir(), []FilterConfig{{Tag: "bad", Type: "console", Level: "info",
+		Properties: map[string]string{"sampling": "sometimes"}}})
+	if nil == err {
+		t.Error("bad sampling property accepted")
+	}
+}
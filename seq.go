@@ -0,0 +1,22 @@
+package logx
+
+import "sync/atomic"
+
+// seqEnabled is 1 when entries get a seq field
+var seqEnabled int32
+
+// SetSequenceField adds a seq field to every entry of the managed loggers,
+// counting up from 1 per logger; an entry carries the same number on every
+// output of its logger. Numbers are taken while the entry is being
+// written, so they increase in output order even when logging from many
+// goroutines, and entries dropped by sampling or deduplication leave no
+// gap: a missing number downstream is a lost line. On loggers with several
+// outputs that holds for the first one; the others may write entries
+// logged at the same moment out of order, and skip those they sample out.
+func SetSequenceField(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&seqEnabled, v)
+}
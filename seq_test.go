@@ -0,0 +1,105 @@
+package logx
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSequenceField(t *testing.T) {
+	SetSequenceField(true)
+	defer SetSequenceField(false)
+
+	out := &syncBuffer{}
+	l := newTestLogger("seq", out)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.Info("concurrent")
+			}
+		}()
+	}
+	wg.Wait()
+
+	seqRe := regexp.MustCompile(` seq=(\d+)`)
+	lines := out.Lines()
+	if len(lines) != 800 {
+		t.Fatalf("%d lines", len(lines))
+	}
+	for i, line := range lines {
+		m := seqRe.FindStringSubmatch(line)
+		if nil == m {
+			t.Fatalf("no seq in %q", line)
+		}
+		if n, _ := strconv.Atoi(m[1]); n != i+1 {
+			t.Fatalf("line %d has seq %d", i+1, n)
+		}
+	}
+
+	// each logger counts on its own
+	other := &syncBuffer{}
+	newTestLogger("seq2", other).Info("first")
+	if m := seqRe.FindStringSubmatch(other.Lines()[0]); nil == m || m[1] != "1" {
+		t.Errorf("second logger line %q", other.Lines()[0])
+	}
+}
+
+func TestSequenceFieldOutputs(t *testing.T) {
+	defer Snapshot()()
+	SetSequenceField(true)
+	defer SetSequenceField(false)
+	opened := registerRecording(t, "recording")
+	err := InitLoggerFromFilters(t.TempDir(), []FilterConfig{{Tag: "seqs", Level: "info", Outputs: []OutputConfig{
+		{Type: "recording"},
+		{Type: "recording"},
+	}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l := GetLogger("seqs")
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				l.Infof("entry %d-%d", g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	re := regexp.MustCompile(`(entry [0-9-]+) seq=(\d+)`)
+	var numbered []map[string]string
+	for _, w := range *opened {
+		seqs := make(map[string]string)
+		seen := make(map[string]bool)
+		for _, line := range w.lines {
+			m := re.FindStringSubmatch(line)
+			if nil == m {
+				t.Fatalf("no seq in %q", line)
+			}
+			if seen[m[2]] {
+				t.Fatalf("seq %s given twice", m[2])
+			}
+			seen[m[2]] = true
+			seqs[m[1]] = m[2]
+		}
+		if len(seqs) != 200 || !seen["1"] || !seen["200"] {
+			t.Fatalf("%d entries numbered", len(seqs))
+		}
+		numbered = append(numbered, seqs)
+	}
+	if len(numbered) != 2 {
+		t.Fatalf("%d outputs", len(numbered))
+	}
+	for msg, n := range numbered[0] {
+		if numbered[1][msg] != n {
+			t.Errorf("%s has seq %s and %s", msg, n, numbered[1][msg])
+		}
+	}
+}
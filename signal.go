@@ -0,0 +1,50 @@
+package logx
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// reraise hands the signal back to the process once the loggers are closed
+var reraise = func(sig os.Signal) {
+	if p, err := os.FindProcess(os.Getpid()); nil == err {
+		if nil == p.Signal(sig) {
+			return
+		}
+	}
+	os.Exit(1)
+}
+
+// InstallSignalFlush closes all loggers when one of sigs (SIGINT and SIGTERM
+// by default) arrives, then re-raises the signal so the default action
+// still happens. It is opt-in: nothing is installed unless it is called, so
+// applications that manage signals themselves are left alone. The returned
+// cancel removes the handler.
+func InstallSignalFlush(sigs ...os.Signal) (cancel func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		select {
+		case sig := <-ch:
+			signal.Stop(ch)
+			Close()
+			reraise(sig)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !windows
+// +build !windows
+
+package logx
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type closeRecorder struct {
+	closed chan struct{}
+}
+
+func (c *closeRecorder) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (c *closeRecorder) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func TestInstallSignalFlush(t *testing.T) {
+	w := &closeRecorder{closed: make(chan struct{})}
+	lock.Lock()
+	outputs["signal-test"] = w
+	lock.Unlock()
+
+	raised := make(chan os.Signal, 1)
+	old := reraise
+	reraise = func(sig os.Signal) { raised <- sig }
+	defer func() { reraise = old }()
+
+	cancel := InstallSignalFlush(syscall.SIGUSR1)
+	defer cancel()
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+
+	select {
+	case sig := <-raised:
+		if sig != syscall.SIGUSR1 {
+			t.Fatalf("re-raised %v, want SIGUSR1", sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("signal was not handled")
+	}
+	select {
+	case <-w.closed:
+	default:
+		t.Fatal("output was not closed before re-raise")
+	}
+}
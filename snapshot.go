@@ -0,0 +1,59 @@
+package logx
+
+import (
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Snapshot captures the managed loggers, their outputs, the package hooks
+// and fields and the hooks of the logrus standard logger, and returns a
+// function that puts them back. Tests use it as
+//
+//	defer logx.Snapshot()()
+//
+// so loggers configured by one test do not leak into the next. Writers
+// opened after the snapshot are not closed by the restore.
+func Snapshot() func() {
+	lock.RLock()
+	savedLoggers := make(map[string]*logrus.Logger, len(loggers))
+	for k, v := range loggers {
+		savedLoggers[k] = v
+	}
+	savedOutputs := make(map[string]io.Writer, len(outputs))
+	for k, v := range outputs {
+		savedOutputs[k] = v
+	}
+	savedDestinations := make(map[string]string, len(destinations))
+	for k, v := range destinations {
+		savedDestinations[k] = v
+	}
+	savedConfigs := make(map[string]FilterConfig, len(configs))
+	for k, v := range configs {
+		savedConfigs[k] = v
+	}
+	savedHooks := append([]logrus.Hook(nil), hooks...)
+	savedFields, _ := defaultFields.Load().(logrus.Fields)
+	lock.RUnlock()
+	savedStd := copyHooks(logrus.StandardLogger().Hooks)
+
+	return func() {
+		lock.Lock()
+		defer lock.Unlock()
+		loggers = savedLoggers
+		outputs = savedOutputs
+		destinations = savedDestinations
+		configs = savedConfigs
+		hooks = savedHooks
+		defaultFields.Store(savedFields)
+		logrus.StandardLogger().ReplaceHooks(copyHooks(savedStd))
+	}
+}
+
+func copyHooks(h logrus.LevelHooks) logrus.LevelHooks {
+	c := make(logrus.LevelHooks, len(h))
+	for level, list := range h {
+		c[level] = append([]logrus.Hook(nil), list...)
+	}
+	return c
+}
@@ -0,0 +1,35 @@
+package logx
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "before", Type: "console", Level: "info"}}); nil != err {
+		t.Fatal(err)
+	}
+	before := GetLogger("before")
+	stdHooks := len(logrus.StandardLogger().Hooks[logrus.InfoLevel])
+
+	restore := Snapshot()
+	if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "after", Type: "console", Level: "info"}}); nil != err {
+		t.Fatal(err)
+	}
+	if _, ok := OutputWriter("after"); !ok {
+		t.Fatal("reconfiguration not applied")
+	}
+	restore()
+
+	if _, ok := OutputWriter("after"); ok {
+		t.Error("logger configured after the snapshot survived restore")
+	}
+	if GetLogger("before") != before {
+		t.Error("logger configured before the snapshot was lost")
+	}
+	if n := len(logrus.StandardLogger().Hooks[logrus.InfoLevel]); n != stdHooks {
+		t.Errorf("standard logger has %d info hooks after restore, want %d", n, stdHooks)
+	}
+}
@@ -0,0 +1,101 @@
+package logx
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LoggerStats are the counters of one tag since the process started
+type LoggerStats struct {
+	// Emitted counts the entries logged at an enabled level
+	Emitted uint64
+	// Dropped counts the entries outputs discarded for sampling, rate
+	// limiting or a full or expired queue; a tag with several outputs
+	// counts each of them
+	Dropped uint64
+	// Bytes counts the rendered bytes handed to the outputs
+	Bytes uint64
+}
+
+// tagStats holds the counters of one tag and counts its entries as a hook
+type tagStats struct {
+	emitted uint64
+	dropped uint64
+	bytes   uint64
+}
+
+var statsLock sync.Mutex
+var stats = make(map[string]*tagStats)
+
+// statsFor returns the counters of tag, created on first use
+func statsFor(tag string) *tagStats {
+	statsLock.Lock()
+	defer statsLock.Unlock()
+	s, ok := stats[tag]
+	if !ok {
+		s = new(tagStats)
+		stats[tag] = s
+	}
+	return s
+}
+
+// resetStats zeroes the counters of every tag, for tests
+func resetStats() {
+	statsLock.Lock()
+	defer statsLock.Unlock()
+	for _, s := range stats {
+		atomic.StoreUint64(&s.emitted, 0)
+		atomic.StoreUint64(&s.dropped, 0)
+		atomic.StoreUint64(&s.bytes, 0)
+	}
+}
+
+// Stats returns the counters of every tag logged to so far, for metrics
+// without the Prometheus integration. Counters are updated atomically on
+// the logging path and never reset.
+func Stats() map[string]LoggerStats {
+	statsLock.Lock()
+	defer statsLock.Unlock()
+	report := make(map[string]LoggerStats, len(stats))
+	for tag, s := range stats {
+		report[tag] = LoggerStats{
+			Emitted: atomic.LoadUint64(&s.emitted),
+			Dropped: atomic.LoadUint64(&s.dropped),
+			Bytes:   atomic.LoadUint64(&s.bytes),
+		}
+	}
+	return report
+}
+
+func (s *tagStats) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (s *tagStats) Fire(*logrus.Entry) error {
+	atomic.AddUint64(&s.emitted, 1)
+	return nil
+}
+
+// drop and wrote are nil-safe for writers built without a tag
+func (s *tagStats) drop() {
+	if nil != s {
+		atomic.AddUint64(&s.dropped, 1)
+	}
+}
+
+func (s *tagStats) wrote(out []byte) {
+	if nil != s {
+		atomic.AddUint64(&s.bytes, uint64(len(out)))
+	}
+}
+
+// countDrops makes the queue of w, if any, count its drops for tag
+func countDrops(w io.Writer, tag string) io.Writer {
+	if q, ok := w.(*queuedWriter); ok {
+		q.stats = statsFor(tag)
+	}
+	return w
+}
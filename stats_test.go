@@ -0,0 +1,44 @@
+package logx
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	defer Snapshot()()
+	resetStats()
+	defer resetStats()
+	SetRateLimit(map[string]float64{"stats-work": 10})
+	defer SetRateLimit(nil)
+
+	dir := t.TempDir()
+	if err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "stats-work", Type: "file", Level: "info"}}); nil != err {
+		t.Fatal(err)
+	}
+	l := GetLogger("stats-work")
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		l.WithTime(start.Add(time.Duration(i) * 5 * time.Millisecond)).Info("work")
+	}
+	l.Debug("below the level")
+	Flush()
+
+	s := Stats()["stats-work"]
+	if s.Emitted != 100 {
+		t.Errorf("Emitted = %d, want 100", s.Emitted)
+	}
+	// a burst of 10 passes the limit within the half second
+	if s.Dropped < 85 || s.Dropped > 90 {
+		t.Errorf("Dropped = %d of 100 at 10/s", s.Dropped)
+	}
+	fi, err := os.Stat(path.Join(dir, "stats-work.log"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if s.Bytes != uint64(fi.Size()) {
+		t.Errorf("Bytes = %d, file holds %d", s.Bytes, fi.Size())
+	}
+}
@@ -0,0 +1,128 @@
+package logx
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// streamBuffer is the number of entries buffered for a slow stream client;
+// entries past it are dropped for that client
+const streamBuffer = 256
+
+// streamClients are the connected clients of StreamHandler, by tag
+var streamLock sync.RWMutex
+var streamClients = make(map[string]map[*streamClient]bool)
+
+type streamClient struct {
+	threshold logrus.Level
+	entries   chan []byte
+}
+
+// streamHook fans the entries of one logger out to its stream clients
+type streamHook struct {
+	tag string
+}
+
+func (h *streamHook) withTag(tag string) logrus.Hook {
+	return &streamHook{tag: tag}
+}
+
+func (h *streamHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+var streamFormatter = &logrus.JSONFormatter{}
+
+func (h *streamHook) Fire(e *logrus.Entry) error {
+	streamLock.RLock()
+	defer streamLock.RUnlock()
+	clients := streamClients[h.tag]
+	if len(clients) == 0 {
+		return nil
+	}
+	p, err := streamFormatter.Format(cloneEntry(e))
+	if nil != err {
+		return err
+	}
+	p = bytes.TrimRight(p, "\n")
+	for c := range clients {
+		if e.Level > c.threshold {
+			continue
+		}
+		select {
+		case c.entries <- p:
+		default:
+		}
+	}
+	return nil
+}
+
+// StreamHandler serves the entries of the logger named tag, as they are
+// logged, as a Server-Sent Events stream of JSON entries. The level query
+// parameter, e.g. ?level=warn, drops the less severe entries. A client
+// falling more than 256 entries behind misses entries rather than slowing
+// down the logger.
+func StreamHandler(tag string) http.Handler {
+	installStreamHook()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		c := &streamClient{threshold: logrus.TraceLevel, entries: make(chan []byte, streamBuffer)}
+		if v := r.URL.Query().Get("level"); v != "" {
+			level, err := logrus.ParseLevel(v)
+			if nil != err {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			c.threshold = level
+		}
+
+		streamLock.Lock()
+		if nil == streamClients[tag] {
+			streamClients[tag] = make(map[*streamClient]bool)
+		}
+		streamClients[tag][c] = true
+		streamLock.Unlock()
+		defer func() {
+			streamLock.Lock()
+			delete(streamClients[tag], c)
+			streamLock.Unlock()
+		}()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write([]byte(": streaming " + tag + "\n\n"))
+		flusher.Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case p := <-c.entries:
+				w.Write([]byte("data: "))
+				w.Write(p)
+				w.Write([]byte("\n\n"))
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// installStreamHook adds the stream hook to the managed loggers unless it
+// is there already
+func installStreamHook() {
+	lock.RLock()
+	for _, h := range hooks {
+		if _, ok := h.(*streamHook); ok {
+			lock.RUnlock()
+			return
+		}
+	}
+	lock.RUnlock()
+	addHook(&streamHook{})
+}
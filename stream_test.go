@@ -0,0 +1,61 @@
+package logx
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamHandler(t *testing.T) {
+	defer Snapshot()()
+	l := newTestLogger("live", &syncBuffer{})
+	loggers["live"] = l
+	srv := httptest.NewServer(StreamHandler("live"))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?level=info", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("content type %q", ct)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	lines.Scan() // the comment sent once subscribed
+
+	l.Debug("below the stream level")
+	l.WithField("user", "ann").Info("live entry")
+	var data string
+	for lines.Scan() {
+		if strings.HasPrefix(lines.Text(), "data: ") {
+			data = lines.Text()
+			break
+		}
+	}
+	if !strings.Contains(data, `"msg":"live entry"`) || !strings.Contains(data, `"user":"ann"`) {
+		t.Errorf("received %q", data)
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for {
+		streamLock.RLock()
+		n := len(streamClients["live"])
+		streamLock.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("client still subscribed after disconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
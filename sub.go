@@ -0,0 +1,31 @@
+package logx
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Component is an entry of a managed logger bound to a dotted component
+// path. It logs like the entry it embeds, and Sub extends the path.
+type Component struct {
+	*logrus.Entry
+	path string
+}
+
+// Sub returns the logger named tag bound to the component tag.segment, so
+//
+//	logx.Sub("app", "http").Sub("handler").Info("ready")
+//
+// logs with component=app.http.handler.
+func Sub(tag string, segment string) *Component {
+	return (&Component{Entry: logrus.NewEntry(GetLogger(tag)), path: tag}).Sub(segment)
+}
+
+// Sub returns a child of c whose path ends with segment; its fields are
+// those of c
+func (c *Component) Sub(segment string) *Component {
+	path := c.path
+	if segment != "" {
+		path += "." + segment
+	}
+	return &Component{Entry: c.Entry.WithField("component", path), path: path}
+}
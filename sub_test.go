@@ -0,0 +1,29 @@
+package logx
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestSub(t *testing.T) {
+	defer Snapshot()()
+	logger, hook := test.NewNullLogger()
+	lock.Lock()
+	loggers["app"] = logger
+	lock.Unlock()
+
+	http := Sub("app", "http")
+	http.Sub("handler").WithField("route", "/ping").Info("ready")
+	e := hook.LastEntry()
+	if e.Data["component"] != "app.http.handler" || e.Data["route"] != "/ping" {
+		t.Errorf("fields %v", e.Data)
+	}
+	http.Info("listening")
+	if c := hook.LastEntry().Data["component"]; c != "app.http" {
+		t.Errorf("parent component %v after a child was made", c)
+	}
+	if e.Logger != logger {
+		t.Error("entry not bound to the tag's logger")
+	}
+}
@@ -0,0 +1,100 @@
+package logx
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// describeFilter names the destination of a filter, e.g. "file logs/app.log"
+func describeFilter(logPath string, fc FilterConfig) string {
+	fc, _ = expandForward(fc)
+	if len(fc.Outputs) > 0 {
+		parts := make([]string, 0, len(fc.Outputs))
+		for _, oc := range fc.Outputs {
+			props := make(map[string]string, len(fc.Properties)+len(oc.Properties))
+			for k, v := range fc.Properties {
+				props[k] = v
+			}
+			for k, v := range oc.Properties {
+				props[k] = v
+			}
+			level := oc.Level
+			if level == "" {
+				level = fc.Level
+			}
+			parts = append(parts, describeOutput(logPath, fc.Tag, oc.Type, props)+"@"+strings.ToLower(level))
+		}
+		return strings.Join(parts, " + ")
+	}
+	return describeOutput(logPath, fc.Tag, fc.Type, fc.Properties)
+}
+
+func describeOutput(logPath, tag, typ string, props map[string]string) string {
+	switch typ {
+	case "console":
+		return "console"
+	case "file":
+		if split, _ := strconv.ParseBool(props["splitlevels"]); split {
+			return "file " + path.Join(logPath, tag+".<level>.log")
+		}
+		if tmpl := props["filetemplate"]; tmpl != "" {
+			return "file " + path.Join(logPath, tmpl)
+		}
+		if n, _ := strconv.Atoi(props["shards"]); n > 1 {
+			return fmt.Sprintf("file %s.{0..%d}.log", path.Join(logPath, tag), n-1)
+		}
+		return "file " + path.Join(logPath, tag+".log")
+	case "network":
+		network := props["network"]
+		if network == "" {
+			network = "tcp"
+		}
+		return "network " + network + "://" + props["address"]
+	case "syslog":
+		if props["address"] == "" {
+			return "syslog"
+		}
+		return "syslog " + props["network"] + "://" + props["address"]
+	case "eventlog":
+		return "eventlog " + props["source"]
+	}
+	outputTypesLock.Lock()
+	ot, ok := outputTypes[typ]
+	outputTypesLock.Unlock()
+	switch {
+	case ok && nil != ot.describe:
+		return ot.describe(tag, props)
+	case ok:
+		return typ
+	}
+	return "default output"
+}
+
+// LogConfigSummary writes one Info line through the stdout logger listing
+// every configured tag with its level and destination, e.g.
+//
+//	logging configured: app=info file logs/app.log, stdout=info file logs/stdout.log
+//
+// Call it after InitLogger to confirm in the logs that the intended
+// configuration loaded.
+func LogConfigSummary() {
+	lock.RLock()
+	tags := make([]string, 0, len(loggers))
+	for tag := range loggers {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	parts := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		dest, ok := destinations[tag]
+		if !ok {
+			dest = "unknown"
+		}
+		parts = append(parts, tag+"="+loggers[tag].GetLevel().String()+" "+dest)
+	}
+	lock.RUnlock()
+	GetLogger("stdout").Info("logging configured: " + strings.Join(parts, ", "))
+}
@@ -0,0 +1,48 @@
+package logx
+
+import (
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogConfigSummary(t *testing.T) {
+	defer Snapshot()()
+	lock.Lock()
+	loggers = make(map[string]*logrus.Logger)
+	lock.Unlock()
+
+	registerRecordingDescribed(t, "otlp", func(tag string, props map[string]string) string {
+		return "otlp " + props["endpoint"]
+	})
+	registerRecording(t, "recording")
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{
+		{Tag: "app", Type: "file", Level: "info"},
+		{Tag: "trace", Type: "console", Level: "debug"},
+		{Tag: "otel", Type: "otlp", Level: "warn", Properties: map[string]string{"endpoint": "http://otel:4318"}},
+		{Tag: "rec", Type: "recording", Level: "info"},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	out := &syncBuffer{}
+	GetLogger("stdout").SetOutput(out)
+
+	LogConfigSummary()
+	line := out.Lines()[0]
+	for _, want := range []string{
+		"app=info file " + path.Join(dir, "app.log"),
+		"trace=debug console",
+		"otel=warning otlp http://otel:4318",
+		"rec=info recording",
+		"stdout=info file " + path.Join(dir, "stdout.log"),
+		"stderr=error file",
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("summary %q is missing %q", line, want)
+		}
+	}
+}
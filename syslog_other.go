@@ -0,0 +1,14 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package logx
+
+import (
+	"errors"
+	"io"
+)
+
+// syslogOutput is not available where log/syslog is not
+func syslogOutput(props map[string]string) (io.Writer, error) {
+	return nil, errors.New("syslog output is not supported on this platform")
+}
@@ -0,0 +1,60 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package logx
+
+import (
+	"io"
+	"log/syslog"
+
+	"github.com/sirupsen/logrus"
+)
+
+// syslogWriter sends entries to syslog with the priority of their level
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+// syslogOutput dials the syslog daemon at the network and address
+// properties, or the local one when address is empty. Messages are tagged
+// with the syslogtag property, the program name by default.
+func syslogOutput(props map[string]string) (io.Writer, error) {
+	network := props["network"]
+	if network == "" && props["address"] != "" {
+		network = "udp"
+	}
+	w, err := syslog.Dial(network, props["address"], syslog.LOG_INFO|syslog.LOG_USER, props["syslogtag"])
+	if nil != err {
+		return nil, err
+	}
+	return &syslogWriter{w: w}, nil
+}
+
+func (s *syslogWriter) Write(p []byte) (int, error) {
+	return s.WriteLevel(logrus.InfoLevel, p)
+}
+
+func (s *syslogWriter) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	msg := string(p)
+	var err error
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		err = s.w.Crit(msg)
+	case logrus.ErrorLevel:
+		err = s.w.Err(msg)
+	case logrus.WarnLevel:
+		err = s.w.Warning(msg)
+	case logrus.InfoLevel:
+		err = s.w.Info(msg)
+	default:
+		err = s.w.Debug(msg)
+	}
+	if nil != err {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *syslogWriter) Close() error {
+	return s.w.Close()
+}
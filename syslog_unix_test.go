@@ -0,0 +1,56 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package logx
+
+import (
+	"io/ioutil"
+	"net"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestForwardToSyslog(t *testing.T) {
+	defer Snapshot()()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	dir := t.TempDir()
+	err = InitLoggerFromFilters(dir, []FilterConfig{{Tag: "audit", Type: "file", Level: "info", Properties: map[string]string{
+		"forward":      "syslog://" + conn.LocalAddr().String(),
+		"forwardlevel": "error",
+		"syslogtag":    "logx-test",
+	}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l := GetLogger("audit")
+	l.Info("local only")
+	l.Error("local and central")
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buf)
+	if nil != err {
+		t.Fatal(err)
+	}
+	// <11> is user.err
+	if msg := string(buf[:n]); !strings.HasPrefix(msg, "<11>") || !strings.Contains(msg, "logx-test") ||
+		!strings.Contains(msg, "local and central") {
+		t.Errorf("syslog got %q", msg)
+	}
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if n, _, err := conn.ReadFrom(buf); nil == err {
+		t.Errorf("syslog got a second message %q", buf[:n])
+	}
+
+	content, _ := ioutil.ReadFile(path.Join(dir, "audit.log"))
+	if !strings.Contains(string(content), "local only") || !strings.Contains(string(content), "local and central") {
+		t.Errorf("audit.log holds %q", content)
+	}
+}
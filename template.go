@@ -0,0 +1,240 @@
+package logx
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultMaxOpen bounds the files a filetemplate output keeps open
+const defaultMaxOpen = 64
+
+// entryWriter is implemented by outputs that pick their destination from
+// the fields of each entry. Like levelWriter outputs they are fed by a hook.
+type entryWriter interface {
+	WriteEntry(e *logrus.Entry, p []byte) (int, error)
+}
+
+// entryHook renders entries up to threshold and hands them to an entryWriter
+type entryHook struct {
+	w         entryWriter
+	threshold logrus.Level
+	formatter logrus.Formatter
+}
+
+func (h *entryHook) Levels() []logrus.Level {
+	return levelsUpTo(h.threshold)
+}
+
+func (h *entryHook) Fire(e *logrus.Entry) error {
+	p, err := h.formatter.Format(e)
+	if nil != err || len(p) == 0 {
+		return err
+	}
+	_, err = h.w.WriteEntry(e, p)
+	return err
+}
+
+// templateOutput opens a file output whose path, relative to logPath, is
+// the filetemplate property with every {field} replaced by that field of
+// the entry, e.g. tenant-{tenant}.log. Files are opened on first use with
+// the rotation properties of the filter and at most maxopen (64 by
+// default) stay open, the least recently written being closed first.
+// Entries lacking a field go to the usual tag.log.
+func templateOutput(logPath string, tag string, props map[string]string) (io.Writer, error) {
+	parts, err := parseTemplate(props["filetemplate"])
+	if nil != err {
+		return nil, fmt.Errorf("%s: %w", tag, err)
+	}
+	max := defaultMaxOpen
+	if v, ok := props["maxopen"]; ok {
+		if max, err = strconv.Atoi(strings.TrimSpace(v)); nil != err || max < 1 {
+			return nil, fmt.Errorf("%s: maxopen %q is not a positive number", tag, v)
+		}
+	}
+	filename := path.Join(logPath, tag+".log")
+	fallback, err := fileOutput(nil, filename, tag, props)
+	if nil != err {
+		return nil, err
+	}
+	return &templateWriter{
+		dir:      logPath,
+		tag:      tag,
+		parts:    parts,
+		props:    props,
+		max:      max,
+		name:     filename,
+		fallback: fallback,
+		open:     make(map[string]*list.Element),
+		lru:      list.New(),
+	}, nil
+}
+
+// templatePart is a literal run of a file template or, when field is set,
+// a field reference
+type templatePart struct {
+	text  string
+	field bool
+}
+
+func parseTemplate(tmpl string) ([]templatePart, error) {
+	var parts []templatePart
+	hasField := false
+	for rest := tmpl; rest != ""; {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			parts = append(parts, templatePart{text: rest})
+			break
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("filetemplate %q: unclosed {", tmpl)
+		}
+		name := strings.TrimSpace(rest[open+1 : open+end])
+		if name == "" {
+			return nil, fmt.Errorf("filetemplate %q: empty field name", tmpl)
+		}
+		if open > 0 {
+			parts = append(parts, templatePart{text: rest[:open]})
+		}
+		parts = append(parts, templatePart{text: name, field: true})
+		hasField = true
+		rest = rest[open+end+1:]
+	}
+	if !hasField {
+		return nil, fmt.Errorf("filetemplate %q references no field", tmpl)
+	}
+	return parts, nil
+}
+
+// templateWriter is the output of a filetemplate filter
+type templateWriter struct {
+	mu       sync.Mutex
+	dir      string
+	tag      string
+	parts    []templatePart
+	props    map[string]string
+	max      int
+	name     string // of the fallback file
+	fallback io.Writer
+	open     map[string]*list.Element // of *templateFile, by name
+	lru      *list.List               // most recently written first
+}
+
+// templateFile is one open file of a templateWriter
+type templateFile struct {
+	name string
+	w    io.Writer
+}
+
+// expand renders the file name for fields; ok is false when a field is
+// missing or empty
+func (t *templateWriter) expand(fields logrus.Fields) (name string, ok bool) {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if !part.field {
+			b.WriteString(part.text)
+			continue
+		}
+		v, found := fields[part.text]
+		if !found {
+			return "", false
+		}
+		value := sanitizePathValue(fmt.Sprint(v))
+		if value == "" {
+			return "", false
+		}
+		b.WriteString(value)
+	}
+	return b.String(), true
+}
+
+// sanitizePathValue keeps field values from leaving the log path: anything
+// but letters, digits, '-', '_' and '.' becomes '_', and values made of
+// dots only are dropped
+func sanitizePathValue(v string) string {
+	if strings.Trim(v, ".") == "" {
+		return ""
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, v)
+}
+
+func (t *templateWriter) Write(p []byte) (int, error) {
+	return t.fallback.Write(p)
+}
+
+func (t *templateWriter) WriteEntry(e *logrus.Entry, p []byte) (int, error) {
+	w := t.fallback
+	if name, ok := t.expand(e.Data); ok {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		var err error
+		if w, err = t.file(name); nil != err {
+			return 0, err
+		}
+	}
+	n, err := w.Write(p)
+	if nil == err && e.Level <= logrus.FatalLevel {
+		err = flushOutput(w)
+	}
+	return n, err
+}
+
+// file returns the open writer of name, opening it and closing the least
+// recently used one when over max; callers hold mu
+func (t *templateWriter) file(name string) (io.Writer, error) {
+	if el, ok := t.open[name]; ok {
+		t.lru.MoveToFront(el)
+		return el.Value.(*templateFile).w, nil
+	}
+	w, err := fileOutput(nil, path.Join(t.dir, name), t.tag, t.props)
+	if nil != err {
+		return nil, err
+	}
+	f := &templateFile{name: name, w: w}
+	t.open[name] = t.lru.PushFront(f)
+	for t.lru.Len() > t.max {
+		evicted := t.lru.Remove(t.lru.Back()).(*templateFile)
+		delete(t.open, evicted.name)
+		closeWriter(evicted.w)
+	}
+	return f.w, nil
+}
+
+func (t *templateWriter) Flush() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	first := flushOutput(t.fallback)
+	for _, el := range t.open {
+		if err := flushOutput(el.Value.(*templateFile).w); nil != err && nil == first {
+			first = err
+		}
+	}
+	return first
+}
+
+func (t *templateWriter) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	first := closeWriter(t.fallback)
+	for name, el := range t.open {
+		if err := closeWriter(el.Value.(*templateFile).w); nil != err && nil == first {
+			first = err
+		}
+		delete(t.open, name)
+	}
+	t.lru.Init()
+	return first
+}
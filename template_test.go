@@ -0,0 +1,91 @@
+package logx
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileTemplate(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "tenants", Type: "file", Level: "info",
+		Properties: map[string]string{"filetemplate": "tenant-{tenant}.log", "maxopen": "2"}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l := GetLogger("tenants")
+	l.WithField("tenant", "a").Info("for a")
+	l.WithField("tenant", "b").Info("for b")
+	l.WithField("tenant", "../b").Info("for dotdot")
+	l.Info("for nobody")
+
+	read := func(name string) string {
+		t.Helper()
+		contents, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if nil != err {
+			t.Fatal(err)
+		}
+		return string(contents)
+	}
+	if got := read("tenant-a.log"); !strings.Contains(got, "for a") || strings.Contains(got, "for b") {
+		t.Errorf("tenant-a.log = %q", got)
+	}
+	if got := read("tenant-b.log"); !strings.Contains(got, "for b") {
+		t.Errorf("tenant-b.log = %q", got)
+	}
+	if got := read("tenants.log"); !strings.Contains(got, "for nobody") {
+		t.Errorf("default file = %q", got)
+	}
+	if got := read("tenant-.._b.log"); !strings.Contains(got, "for dotdot") {
+		t.Errorf("sanitized file = %q", got)
+	}
+
+	// tenant-b.log and tenant-.._b.log are the most recent: a was evicted
+	tw := outputs["tenants"].(*templateWriter)
+	if _, ok := tw.open["tenant-a.log"]; ok || tw.lru.Len() != 2 {
+		t.Errorf("open files %v, want a evicted", tw.open)
+	}
+	l.WithField("tenant", "a").Info("a again")
+	if _, ok := tw.open["tenant-b.log"]; ok {
+		t.Error("b not evicted when a reopened")
+	}
+	if got := read("tenant-a.log"); !strings.Contains(got, "for a") || !strings.Contains(got, "a again") {
+		t.Errorf("reopened tenant-a.log = %q", got)
+	}
+}
+
+func TestFileTemplateErrors(t *testing.T) {
+	for _, tmpl := range []string{"static.log", "tenant-{tenant.log", "tenant-{}.log"} {
+		_, _, err := BuildLoggers(t.TempDir(), []FilterConfig{{Tag: "bad", Type: "file", Level: "info",
+			Properties: map[string]string{"filetemplate": tmpl}}})
+		if nil == err {
+			t.Errorf("%s: no error", tmpl)
+		}
+	}
+}
+
+func TestFileTemplateFileProperties(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "tenants", Type: "file", Level: "info",
+		Properties: map[string]string{"filetemplate": "tenant-{tenant}.log", "recordsep": `\0`, "bufsize": "64K", "flushinterval": "1h"}}})
+	if nil != err {
+		t.Fatal(err)
+	}
+	l := GetLogger("tenants")
+	l.WithField("tenant", "a").Info("first")
+	l.WithField("tenant", "a").Info("second")
+	read := func() string {
+		contents, _ := ioutil.ReadFile(filepath.Join(dir, "tenant-a.log"))
+		return string(contents)
+	}
+	if got := read(); got != "" {
+		t.Fatalf("bufsize ignored, file holds %q", got)
+	}
+	Flush()
+	if records := strings.Split(read(), "\x00"); len(records) != 3 || !strings.Contains(records[1], "second") {
+		t.Errorf("recordsep ignored, records %q", records)
+	}
+}
@@ -0,0 +1,53 @@
+package logx
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WithTraceparent returns an entry of the logger named tag carrying the
+// trace_id and span_id of a W3C traceparent header, such as
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01. A malformed
+// header is ignored and the entry comes without them.
+func WithTraceparent(tp string, tag string) *logrus.Entry {
+	e := logrus.NewEntry(GetLogger(tag))
+	traceID, spanID, ok := parseTraceparent(tp)
+	if !ok {
+		return e
+	}
+	return e.WithFields(logrus.Fields{"trace_id": traceID, "span_id": spanID})
+}
+
+// parseTraceparent splits version-traceid-spanid-flags. Versions after 00
+// may append fields, version ff and all zero ids are invalid.
+func parseTraceparent(tp string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(tp), "-")
+	if len(parts) < 4 {
+		return "", "", false
+	}
+	version := parts[0]
+	if !isLowerHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return "", "", false
+	}
+	traceID, spanID = parts[1], parts[2]
+	if !isLowerHex(traceID, 32) || !isLowerHex(spanID, 16) || !isLowerHex(parts[3], 2) {
+		return "", "", false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !(s[i] >= '0' && s[i] <= '9' || s[i] >= 'a' && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,25 @@
+package logx
+
+import "testing"
+
+func TestWithTraceparent(t *testing.T) {
+	e := WithTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "stdout")
+	if e.Data["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || e.Data["span_id"] != "00f067aa0ba902b7" {
+		t.Errorf("fields = %v", e.Data)
+	}
+
+	for _, tp := range []string{
+		"",
+		"garbage",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		e := WithTraceparent(tp, "stdout")
+		if len(e.Data) != 0 {
+			t.Errorf("%q: fields = %v", tp, e.Data)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package logx
+
+import (
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const truncatedMarker = "...(truncated)"
+
+var maxFieldLength, maxMessageLength int64
+
+// truncateEntry caps the string field values and the message of e at the
+// configured lengths, on a copy when any is too long
+func truncateEntry(e *logrus.Entry) *logrus.Entry {
+	copied := false
+	if n := int(atomic.LoadInt64(&maxFieldLength)); n > 0 {
+		for k, v := range e.Data {
+			if s, ok := v.(string); ok && len(s) > n {
+				if !copied {
+					e, copied = cloneEntry(e), true
+				}
+				e.Data[k] = truncate(s, n)
+			}
+		}
+	}
+	if n := int(atomic.LoadInt64(&maxMessageLength)); n > 0 && len(e.Message) > n {
+		if !copied {
+			e = cloneEntry(e)
+		}
+		e.Message = truncate(e.Message, n)
+	}
+	return e
+}
+
+// truncate cuts s to at most n bytes without splitting a rune
+func truncate(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + truncatedMarker
+}
+
+// SetMaxFieldLength truncates string field values longer than n bytes to n
+// bytes followed by "...(truncated)" in every output of the managed
+// loggers. Zero, the default, means unlimited.
+func SetMaxFieldLength(n int) {
+	atomic.StoreInt64(&maxFieldLength, int64(n))
+}
+
+// SetMaxMessageLength caps messages the same way SetMaxFieldLength caps
+// field values.
+func SetMaxMessageLength(n int) {
+	atomic.StoreInt64(&maxMessageLength, int64(n))
+}
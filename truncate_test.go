@@ -0,0 +1,88 @@
+package logx
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestTruncation(t *testing.T) {
+	SetMaxFieldLength(8)
+	SetMaxMessageLength(5)
+	defer SetMaxFieldLength(0)
+	defer SetMaxMessageLength(0)
+
+	f := newEntryFormatter("truncate", &logrus.JSONFormatter{})
+	l := logrus.New()
+	format := func(e *logrus.Entry) map[string]interface{} {
+		p, err := f.Format(e)
+		if nil != err {
+			t.Fatal(err)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(p, &got); nil != err {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	e := l.WithFields(logrus.Fields{
+		"blob":  strings.Repeat("A", 1024),
+		"short": "ok",
+		"utf8":  "aéééé",
+		"num":   123456789012,
+	})
+	e.Message = "a rather long message"
+	got := format(e)
+	if got["blob"] != "AAAAAAAA...(truncated)" {
+		t.Errorf("blob = %q", got["blob"])
+	}
+	if got["short"] != "ok" || got["num"] != float64(123456789012) {
+		t.Errorf("short values changed: %v", got)
+	}
+	if got["utf8"] != "aééé...(truncated)" {
+		t.Errorf("utf8 = %q", got["utf8"])
+	}
+	if got["msg"] != "a rat...(truncated)" {
+		t.Errorf("message = %q", got["msg"])
+	}
+	if len(e.Data["blob"].(string)) != 1024 || e.Message != "a rather long message" {
+		t.Error("the entry of the caller was changed")
+	}
+
+	SetMaxFieldLength(0)
+	SetMaxMessageLength(0)
+	got = format(e)
+	if len(got["blob"].(string)) != 1024 || got["msg"] != "a rather long message" {
+		t.Error("zero length still truncates")
+	}
+}
+
+func TestTruncationMultipleOutputs(t *testing.T) {
+	defer Snapshot()()
+	dir := t.TempDir()
+	console, restore := redirectStdout(t)
+	err := InitLoggerFromFilters(dir, []FilterConfig{{Tag: "m", Level: "info", Outputs: []OutputConfig{
+		{Type: "file"},
+		{Type: "console"},
+	}}})
+	restore()
+	if nil != err {
+		t.Fatal(err)
+	}
+	SetMaxFieldLength(5)
+	defer SetMaxFieldLength(0)
+
+	GetLogger("m").WithField("blob", "0123456789abcdef").Info("capped")
+	Flush()
+	for _, name := range []string{path.Join(dir, "m.log"), console.Name()} {
+		content, _ := ioutil.ReadFile(name)
+		if !strings.Contains(string(content), "01234...(truncated)") || strings.Contains(string(content), "56789") {
+			t.Errorf("%s not truncated: %q", name, content)
+		}
+	}
+}
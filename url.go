@@ -0,0 +1,64 @@
+package logx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// configFetchTimeout bounds the whole request of InitLoggerURL
+var configFetchTimeout = 10 * time.Second
+
+// InitLoggerURL fetches the configuration served at url and builds the
+// loggers it describes. A JSON body (application/json, or a body starting
+// with '[') holds a list of FilterConfig; anything else is read as the XML
+// format of InitLogger. The request times out after 10s and any non-2xx
+// status is an error.
+func InitLoggerURL(logPath string, url string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), configFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if nil != err {
+		return fmt.Errorf("InitLoggerURL: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if nil != err {
+		return fmt.Errorf("InitLoggerURL: fetching %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("InitLoggerURL: fetching %s: unexpected status %s", url, resp.Status)
+	}
+	contents, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		return fmt.Errorf("InitLoggerURL: reading %s: %w", url, err)
+	}
+
+	var filters []FilterConfig
+	if isJSONConfig(resp.Header.Get("Content-Type"), contents) {
+		err = json.Unmarshal(contents, &filters)
+	} else {
+		filters, err = parseXMLConfig(contents)
+	}
+	if nil != err {
+		return fmt.Errorf("InitLoggerURL: parsing %s: %w", url, err)
+	}
+	return InitLoggerFromFilters(logPath, filters)
+}
+
+func isJSONConfig(contentType string, contents []byte) bool {
+	if mt, _, err := mime.ParseMediaType(contentType); nil == err {
+		switch mt {
+		case "application/json":
+			return true
+		case "application/xml", "text/xml":
+			return false
+		}
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(contents), []byte("["))
+}
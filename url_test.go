@@ -0,0 +1,58 @@
+package logx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInitLoggerURL(t *testing.T) {
+	defer Snapshot()()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/log.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(`<logging><filter enabled="true"><tag>from-xml</tag><type>console</type><level>DEBUG</level></filter></logging>`))
+	})
+	mux.HandleFunc("/log.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"tag": "from-json", "type": "console", "level": "warn"}]`))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	if err := InitLoggerURL(dir, srv.URL+"/log.xml"); nil != err {
+		t.Fatal(err)
+	}
+	if err := InitLoggerURL(dir, srv.URL+"/log.json"); nil != err {
+		t.Fatal(err)
+	}
+	lock.RLock()
+	xl, jl := loggers["from-xml"], loggers["from-json"]
+	lock.RUnlock()
+	if nil == xl || nil == jl {
+		t.Fatal("configured loggers missing")
+	}
+	if xl.GetLevel().String() != "debug" || jl.GetLevel().String() != "warning" {
+		t.Errorf("levels %s and %s", xl.GetLevel(), jl.GetLevel())
+	}
+
+	if err := InitLoggerURL(dir, srv.URL+"/missing"); nil == err || !strings.Contains(err.Error(), "404") {
+		t.Errorf("404 reported as %v", err)
+	}
+
+	old := configFetchTimeout
+	configFetchTimeout = 50 * time.Millisecond
+	defer func() { configFetchTimeout = old }()
+	if err := InitLoggerURL(dir, srv.URL+"/slow"); nil == err {
+		t.Error("timeout not reported")
+	}
+}